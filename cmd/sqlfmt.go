@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"strconv"
@@ -16,16 +17,48 @@ import (
 var (
 	indentSz       = flag.Int("indent", 4, "")
 	maxLineLen     = flag.Int("l", 120, "")
+	maxBlank       = flag.Int("mbl", 1, "")
+	stmtBlank      = flag.Int("bls", -1, "")
 	configFile     = flag.String("c", "", "")
 	dialectName    = flag.String("d", "standard", "")
 	inputFile      = flag.String("i", "", "")
 	outputFile     = flag.String("o", "", "")
 	keyCase        = flag.String("k", "upper", "")
 	tupleWrapping  = flag.String("t", "none", "")
+	wrapEngine     = flag.String("we", "greedy", "")
+	parenPadding   = flag.String("pp", "on", "")
+	spaceBeforeTrm = flag.String("sbt", "on", "")
 	preserveQuotes = flag.Bool("q", false, "")
+	terminateStmts = flag.Bool("ts", false, "")
+	streamInput    = flag.Bool("stream", false, "")
+	wrapComments   = flag.Bool("wc", false, "")
+	alignComments  = flag.Bool("ac", false, "")
+	alignDecls     = flag.Bool("ad", false, "")
+	formatDynSQL   = flag.Bool("ds", false, "")
 	version        = flag.Bool("version", false, "")
+	bodyFormatters bodyFormatterList
 )
 
+// bodyFormatterList collects the "language=command" values of the (repeatable)
+// body formatter argument
+type bodyFormatterList []string
+
+func (l *bodyFormatterList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *bodyFormatterList) Set(v string) error {
+	if !strings.Contains(v, "=") {
+		return fmt.Errorf("expected language=command, got %q", v)
+	}
+	*l = append(*l, v)
+	return nil
+}
+
+func init() {
+	flag.Var(&bodyFormatters, "bf", "")
+}
+
 func main() {
 	rc := runapp()
 	os.Exit(rc)
@@ -35,16 +68,30 @@ func runapp() (rc int) {
 	flag.Usage = func() {
 		fmt.Fprint(os.Stderr, `usage: sqlfmt [flags]
 
+  -ac       align the trailing comments of consecutive lines (default is false)
+  -ad       align the variable declarations in PL DECLARE sections (default is false)
+  -bf       the command for formatting the bodies of functions in a language other
+            than SQL or PL/pgSQL, as language=command (may be repeated)
+            (e.g. -bf "plpython3u=black -q -")
+  -bls      blank lines between top-level statements (default is -1, preserve the original spacing)
   -c        the configuration file to read
+  -ds       format the DML in dynamic SQL strings passed to EXECUTE and format() (default is false)
   -d        the SQL dialect of the input (default is standard) (standard, postgres, sqlite, mariadb, mssql, mysql, oracle)
   -indent   number of spaces to indent (default is 4), set to 0 to use tabs
   -i        the file to read (defaults to stdin)
   -k        keywords case (default is upper) (upper,lower)
   -l        max line length (defaut is 120)
+  -mbl      max consecutive blank lines (default is 1)
   -o        the file to write to (defaults to stdout)
+  -pp       place spaces inside of parentheses (default is on) (on, off)
   -q        preserve quoted identifiers (default is to unquote identifiers when possible)
+  -sbt      place a space before statement terminators (default is on) (on, off)
+  -stream   read, format, and write the input one statement at a time (default is false)
   -t        multi-tuple wrapping for values statements (default is none) (all, long, none)
+  -ts       ensure that all top-level statements are terminated (default is false)
   -version  display the version information
+  -wc       reflow long comment prose to the max line length (default is false)
+  -we       line wrapping engine (default is greedy) (greedy, optimal)
 `)
 	}
 	flag.Parse()
@@ -54,12 +101,6 @@ func runapp() (rc int) {
 		return 0
 	}
 
-	input, err := readInput(*inputFile)
-	if err != nil {
-		fmt.Fprint(os.Stderr, fmt.Sprintf("%s while reading input %s", err, *inputFile))
-		return 1
-	}
-
 	e := env.NewEnv()
 
 	////////////////////////////////////////////////////////////////////
@@ -107,6 +148,16 @@ func runapp() (rc int) {
 					*maxLineLen = s
 				}
 
+			case "maxblanklines":
+				if s, err := strconv.Atoi(v); err == nil {
+					*maxBlank = s
+				}
+
+			case "blanklinesbetweenstatements":
+				if s, err := strconv.Atoi(v); err == nil {
+					*stmtBlank = s
+				}
+
 			case "preservequoting":
 				switch strings.ToLower(v) {
 				case "on", "true", "t":
@@ -115,21 +166,108 @@ func runapp() (rc int) {
 					*preserveQuotes = false
 				}
 
+			case "parenpadding":
+				*parenPadding = v
+
+			case "spacebeforeterminator":
+				*spaceBeforeTrm = v
+
+			case "terminatestatements":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					*terminateStmts = true
+				default:
+					*terminateStmts = false
+				}
+
+			case "stream":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					*streamInput = true
+				default:
+					*streamInput = false
+				}
+
+			case "wrapcomments":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					*wrapComments = true
+				default:
+					*wrapComments = false
+				}
+
+			case "aligncomments":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					*alignComments = true
+				default:
+					*alignComments = false
+				}
+
+			case "aligndeclarations":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					*alignDecls = true
+				default:
+					*alignDecls = false
+				}
+
+			case "formatdynamicsql":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					*formatDynSQL = true
+				default:
+					*formatDynSQL = false
+				}
+
 			case "wrapmultituples":
 				*tupleWrapping = strings.TrimSpace(p[1])
+
+			case "wrapengine":
+				*wrapEngine = v
+
+			default:
+				if lang, ok := strings.CutPrefix(k, "bodyformatter."); ok {
+					e.SetBodyFormatterCommand(lang, v)
+				}
 			}
 		}
 	}
 
 	////////////////////////////////////////////////////////////////////
 	e.SetMaxLineLength(*maxLineLen)
+	e.SetMaxBlankLines(*maxBlank)
+	e.SetBlankLinesBetweenStatements(*stmtBlank)
 	e.SetKeywordCase(*keyCase)
 	e.SetIndent(*indentSz)
 	e.SetOutputFile(*outputFile)
 	e.SetInputFile(*inputFile)
 	e.SetDialect(*dialectName)
 	e.SetPreserveQuoting(*preserveQuotes)
+	e.SetTerminateStatements(*terminateStmts)
+	e.SetParenPadding(*parenPadding)
+	e.SetSpaceBeforeTerminator(*spaceBeforeTrm)
 	e.SetMultiTupleWrapping(*tupleWrapping)
+	e.SetWrapEngine(*wrapEngine)
+	e.SetWrapComments(*wrapComments)
+	e.SetAlignComments(*alignComments)
+	e.SetAlignDeclarations(*alignDecls)
+	e.SetFormatDynamicSQL(*formatDynSQL)
+	for _, bf := range bodyFormatters {
+		p := strings.SplitN(bf, "=", 2)
+		e.SetBodyFormatterCommand(p[0], p[1])
+	}
+	e.SetStream(*streamInput)
+
+	if e.Stream() {
+		return runStream(e)
+	}
+
+	input, err := readInput(*inputFile)
+	if err != nil {
+		fmt.Fprint(os.Stderr, fmt.Sprintf("%s while reading input %s", err, *inputFile))
+		return 1
+	}
 
 	////////////////////////////////////////////////////////////////////
 	// Read the file directive if specified/found (extract the parsing args
@@ -164,6 +302,76 @@ func runapp() (rc int) {
 	return 0
 }
 
+// runStream reads, formats, and writes the input one top-level statement at
+// a time so that very large files can be formatted without reading the
+// entire file into memory
+func runStream(e *env.Env) int {
+
+	var in io.Reader = os.Stdin
+	switch *inputFile {
+	case "", "-":
+	default:
+		f, err := os.Open(*inputFile)
+		if err != nil {
+			fmt.Fprint(os.Stderr, fmt.Sprintf("%s while reading input %s", err, *inputFile))
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+
+	reader := bufio.NewReader(in)
+
+	////////////////////////////////////////////////////////////////////
+	// Read the file directive if specified/found
+	l1, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		fmt.Fprint(os.Stderr, fmt.Sprintf("%s while reading input %s", err, *inputFile))
+		return 1
+	}
+
+	d1 := strings.TrimLeft(l1, "-#/* \t")
+	if strings.HasPrefix(d1, "sqlfmt") {
+		e.SetDirectives(strings.TrimRight(d1, "\r\n"))
+	}
+
+	if !e.FormatCode() {
+		return 0
+	}
+
+	var out io.Writer = os.Stdout
+	switch *outputFile {
+	case "", "-":
+	default:
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			fmt.Fprint(os.Stderr, fmt.Sprintf("%s while writing output %s", err, *outputFile))
+			return 1
+		}
+		defer f.Close()
+		out = f
+	}
+
+	writer := bufio.NewWriter(out)
+
+	////////////////////////////////////////////////////////////////////
+	warnStrings, errStrings := formatter.FormatStream(e, io.MultiReader(strings.NewReader(l1), reader), writer)
+
+	logStderr("WARNING", *inputFile, warnStrings)
+
+	if err := writer.Flush(); err != nil {
+		fmt.Fprint(os.Stderr, fmt.Sprintf("%s while writing output %s", err, *outputFile))
+		return 1
+	}
+
+	if len(errStrings) > 0 {
+		logStderr("ERROR", *inputFile, errStrings)
+		return 1
+	}
+
+	return 0
+}
+
 func dedupe(s []string) []string {
 	inResult := make(map[string]bool)
 	var result []string
@@ -19,16 +19,37 @@ const (
 	WrapLong
 )
 
+// WrapEngine identifies the engine used for wrapping long lines
+type WrapEngine int
+
+const (
+	WrapGreedy WrapEngine = iota
+	WrapOptimal
+)
+
 type Env struct {
-	keywordCase     int    // Indicates whether to upper-case, lower-case, or leave keywords
-	indentString    string // The character string used for indentation
-	inputFile       string // The file to read from
-	outputFile      string // The file to write to
-	formatCode      bool   // Indicate if there should be any formatting performed or not
-	preserveQuoting bool   // Preserve quoted identifiers (default is to unquote identifiers when possible)
-	wrapMultiTuples int    // Indicates how values with multiple tuples should be wrapped
-	maxLineLength   int    // The suggested maximum line length after which line-wrapping is triggered
+	keywordCase     int        // Indicates whether to upper-case, lower-case, or leave keywords
+	indentString    string     // The character string used for indentation
+	inputFile       string     // The file to read from
+	outputFile      string     // The file to write to
+	formatCode      bool       // Indicate if there should be any formatting performed or not
+	preserveQuoting bool       // Preserve quoted identifiers (default is to unquote identifiers when possible)
+	wrapMultiTuples int        // Indicates how values with multiple tuples should be wrapped
+	wrapEngine      WrapEngine // Indicates which line wrapping engine to use
+	maxLineLength   int        // The suggested maximum line length after which line-wrapping is triggered
+	maxBlankLines   int        // The maximum number of consecutive blank lines to allow
+	stmtBlankLines  int        // The number of blank lines to place between top-level statements (-1 to preserve)
+	terminateStmts  bool       // Ensure that all top-level statements are properly terminated
+	parenPadding    bool       // Place a space inside of parentheses "( a )" vs. "(a)"
+	spaceBeforeTerm bool       // Place a space before statement terminators "a ;" vs. "a;"
+	streamInput     bool       // Read, format, and write the input one statement at a time
+	wrapComments    bool       // Reflow long comment prose to the max line length
+	alignComments   bool       // Align the trailing comments of consecutive lines
+	alignDecls      bool       // Align the variable declarations in PL DECLARE sections
+	formatDynSQL    bool       // Format the DML in dynamic SQL (EXECUTE, format()) strings
 	dbdialect       dialect.DbDialect
+
+	bodyFormatters map[string]string // The commands for formatting non-SQL PL bodies, by language
 }
 
 func NewEnv() *Env {
@@ -41,11 +62,31 @@ func NewEnv() *Env {
 	e.formatCode = true
 	e.preserveQuoting = false
 	e.wrapMultiTuples = WrapNone
+	e.wrapEngine = WrapGreedy
 	e.maxLineLength = 120
+	e.maxBlankLines = 1
+	e.stmtBlankLines = -1
+	e.parenPadding = true
+	e.spaceBeforeTerm = true
 
 	return &e
 }
 
+// Clone returns a copy of the environment that may be modified without
+// affecting the original
+func (e *Env) Clone() *Env {
+	ne := *e
+
+	if e.bodyFormatters != nil {
+		ne.bodyFormatters = make(map[string]string, len(e.bodyFormatters))
+		for k, v := range e.bodyFormatters {
+			ne.bodyFormatters[k] = v
+		}
+	}
+
+	return &ne
+}
+
 func (e *Env) SetString(k, v string) {
 	switch strings.ToLower(k) {
 	case "dialect", "d":
@@ -56,6 +97,12 @@ func (e *Env) SetString(k, v string) {
 		e.SetInputFile(v)
 	case "output", "of":
 		e.SetOutputFile(v)
+	case "parenpadding":
+		e.SetParenPadding(v)
+	case "spacebeforeterminator":
+		e.SetSpaceBeforeTerminator(v)
+	case "wrapengine":
+		e.SetWrapEngine(v)
 	}
 }
 
@@ -65,6 +112,10 @@ func (e *Env) SetInt(k string, v int) {
 		e.SetIndent(v)
 	case "maxlinelength":
 		e.SetMaxLineLength(v)
+	case "maxblanklines":
+		e.SetMaxBlankLines(v)
+	case "blanklinesbetweenstatements":
+		e.SetBlankLinesBetweenStatements(v)
 	}
 }
 
@@ -72,6 +123,22 @@ func (e *Env) SetBool(k string, v bool) {
 	switch strings.ToLower(k) {
 	case "preservequoting":
 		e.preserveQuoting = v
+	case "terminatestatements":
+		e.terminateStmts = v
+	case "parenpadding":
+		e.parenPadding = v
+	case "spacebeforeterminator":
+		e.spaceBeforeTerm = v
+	case "stream":
+		e.streamInput = v
+	case "wrapcomments":
+		e.wrapComments = v
+	case "aligncomments":
+		e.alignComments = v
+	case "aligndeclarations":
+		e.alignDecls = v
+	case "formatdynamicsql":
+		e.formatDynSQL = v
 	case "disableformatting":
 		e.formatCode = false
 	case "enableformatting":
@@ -93,6 +160,129 @@ func (e *Env) SetPreserveQuoting(v bool) {
 	e.preserveQuoting = v
 }
 
+// Statement Terminators ///////////////////////////////////////////////
+
+func (e *Env) TerminateStatements() bool {
+	return e.terminateStmts
+}
+
+func (e *Env) SetTerminateStatements(v bool) {
+	e.terminateStmts = v
+}
+
+// Horizontal Spacing //////////////////////////////////////////////////
+
+func (e *Env) ParenPadding() bool {
+	return e.parenPadding
+}
+
+func (e *Env) SetParenPadding(v string) {
+	e.parenPadding = isOn(v, e.parenPadding)
+}
+
+func (e *Env) SpaceBeforeTerminator() bool {
+	return e.spaceBeforeTerm
+}
+
+func (e *Env) SetSpaceBeforeTerminator(v string) {
+	e.spaceBeforeTerm = isOn(v, e.spaceBeforeTerm)
+}
+
+// isOn interprets an on/off string value, returning the default when the
+// value is not recognized
+func isOn(v string, dflt bool) bool {
+	switch strings.ToLower(v) {
+	case "on", "true", "t":
+		return true
+	case "off", "false", "f":
+		return false
+	}
+	return dflt
+}
+
+// Streaming ///////////////////////////////////////////////////////////
+
+// Stream indicates whether the input is to be read, formatted, and written
+// one top-level statement at a time rather than all at once
+func (e *Env) Stream() bool {
+	return e.streamInput
+}
+
+func (e *Env) SetStream(v bool) {
+	e.streamInput = v
+}
+
+// Comment Wrapping ////////////////////////////////////////////////////
+
+// WrapComments indicates whether long comment prose is to be reflowed to
+// the max line length
+func (e *Env) WrapComments() bool {
+	return e.wrapComments
+}
+
+func (e *Env) SetWrapComments(v bool) {
+	e.wrapComments = v
+}
+
+// AlignComments indicates whether the trailing comments of consecutive
+// lines are to be aligned to a common column
+func (e *Env) AlignComments() bool {
+	return e.alignComments
+}
+
+func (e *Env) SetAlignComments(v bool) {
+	e.alignComments = v
+}
+
+// AlignDeclarations indicates whether the variable declarations in PL
+// DECLARE sections are to be aligned into columns
+func (e *Env) AlignDeclarations() bool {
+	return e.alignDecls
+}
+
+func (e *Env) SetAlignDeclarations(v bool) {
+	e.alignDecls = v
+}
+
+// FormatDynamicSQL indicates whether the DML in the dollar-quoted strings
+// that are passed to EXECUTE and format() is to be formatted
+func (e *Env) FormatDynamicSQL() bool {
+	return e.formatDynSQL
+}
+
+func (e *Env) SetFormatDynamicSQL(v bool) {
+	e.formatDynSQL = v
+}
+
+// Body Formatters /////////////////////////////////////////////////////
+
+// BodyFormatterCommand returns the command (if any) to use for formatting
+// the bodies of functions, procedures, and DO blocks that are written in
+// the specified language
+func (e *Env) BodyFormatterCommand(lang string) (string, bool) {
+	v, ok := e.bodyFormatters[strings.ToLower(lang)]
+	return v, ok
+}
+
+// SetBodyFormatterCommand sets the command to use for formatting the bodies
+// of functions, procedures, and DO blocks that are written in the specified
+// language. An empty command removes the command for the language.
+func (e *Env) SetBodyFormatterCommand(lang, cmd string) {
+
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	cmd = strings.TrimSpace(cmd)
+
+	if cmd == "" {
+		delete(e.bodyFormatters, lang)
+		return
+	}
+
+	if e.bodyFormatters == nil {
+		e.bodyFormatters = make(map[string]string)
+	}
+	e.bodyFormatters[lang] = cmd
+}
+
 // Database Dialect ////////////////////////////////////////////////////
 
 func (e *Env) Dialect() int {
@@ -202,6 +392,34 @@ func (e *Env) SetMaxLineLength(v int) {
 	}
 }
 
+// Blank Lines /////////////////////////////////////////////////////////
+
+func (e *Env) MaxBlankLines() int {
+	return e.maxBlankLines
+}
+
+func (e *Env) SetMaxBlankLines(v int) {
+	if v >= 0 {
+		e.maxBlankLines = v
+	}
+}
+
+// BlankLinesBetweenStatements returns the number of blank lines to place
+// between top-level statements. A negative value indicates that the original
+// spacing (limited by MaxBlankLines) is to be preserved.
+func (e *Env) BlankLinesBetweenStatements() int {
+	return e.stmtBlankLines
+}
+
+func (e *Env) SetBlankLinesBetweenStatements(v int) {
+	switch {
+	case v < 0:
+		e.stmtBlankLines = -1
+	default:
+		e.stmtBlankLines = v
+	}
+}
+
 // Multi-tuple Wrapping ////////////////////////////////////////////////
 
 func (e *Env) WrapMultiTuples() int {
@@ -220,6 +438,25 @@ func (e *Env) SetMultiTupleWrapping(v string) {
 	}
 }
 
+// Line Wrapping Engine ////////////////////////////////////////////////
+
+// WrapEngine indicates which engine is used for wrapping long lines. The
+// greedy engine applies a fixed sequence of wrapping passes while the
+// optimal engine chooses the line breaks that minimize a layout cost
+func (e *Env) WrapEngine() WrapEngine {
+	return e.wrapEngine
+}
+
+func (e *Env) SetWrapEngine(v string) {
+
+	switch strings.ToLower(v) {
+	case "optimal", "cost":
+		e.wrapEngine = WrapOptimal
+	default:
+		e.wrapEngine = WrapGreedy
+	}
+}
+
 // File Directives /////////////////////////////////////////////////////
 
 func (e *Env) SetDirectives(v string) {
@@ -243,8 +480,18 @@ func (e *Env) SetDirectives(v string) {
 			switch strings.ToLower(k) {
 			case "preservequoting":
 				e.preserveQuoting = true
+			case "terminatestatements":
+				e.terminateStmts = true
 			case "noformat":
 				e.formatCode = false
+			case "wrapcomments":
+				e.wrapComments = true
+			case "aligncomments":
+				e.alignComments = true
+			case "aligndeclarations":
+				e.alignDecls = true
+			case "formatdynamicsql":
+				e.formatDynSQL = true
 			}
 
 		case 2:
@@ -268,6 +515,14 @@ func (e *Env) SetDirectives(v string) {
 				if s, err := strconv.Atoi(v); err == nil {
 					e.SetMaxLineLength(s)
 				}
+			case "maxblanklines", "mbl":
+				if s, err := strconv.Atoi(v); err == nil {
+					e.SetMaxBlankLines(s)
+				}
+			case "blanklinesbetweenstatements", "bls":
+				if s, err := strconv.Atoi(v); err == nil {
+					e.SetBlankLinesBetweenStatements(s)
+				}
 			case "preservequoting":
 				switch strings.ToLower(v) {
 				case "on", "true", "t":
@@ -275,6 +530,17 @@ func (e *Env) SetDirectives(v string) {
 				default:
 					e.preserveQuoting = false
 				}
+			case "terminatestatements":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					e.terminateStmts = true
+				default:
+					e.terminateStmts = false
+				}
+			case "parenpadding":
+				e.SetParenPadding(v)
+			case "spacebeforeterminator":
+				e.SetSpaceBeforeTerminator(v)
 			case "noformat":
 				switch strings.ToLower(v) {
 				case "off", "false", "f":
@@ -284,6 +550,36 @@ func (e *Env) SetDirectives(v string) {
 				}
 			case "wrapMultiTuples":
 				e.SetMultiTupleWrapping(v)
+			case "wrapengine":
+				e.SetWrapEngine(v)
+			case "wrapcomments":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					e.wrapComments = true
+				default:
+					e.wrapComments = false
+				}
+			case "aligncomments":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					e.alignComments = true
+				default:
+					e.alignComments = false
+				}
+			case "aligndeclarations":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					e.alignDecls = true
+				default:
+					e.alignDecls = false
+				}
+			case "formatdynamicsql":
+				switch strings.ToLower(v) {
+				case "on", "true", "t":
+					e.formatDynSQL = true
+				default:
+					e.formatDynSQL = false
+				}
 			}
 		}
 	}
@@ -72,7 +72,7 @@ func tagSimple(e *env.Env, m []FmtToken, bagMap map[string]TokenBag, cmdKwd stri
 
 		switch isInBag {
 		case true:
-			if ctVal == ";" {
+			if cTok.IsTerminator() {
 				closeBag = true
 			}
 		case false:
@@ -82,7 +82,7 @@ func tagSimple(e *env.Env, m []FmtToken, bagMap map[string]TokenBag, cmdKwd stri
 			case "/":
 				canOpenBag = e.Dialect() == dialect.Oracle
 			default:
-				canOpenBag = pTok.IsBag()
+				canOpenBag = pTok.IsBag() || pTok.IsData() || pTok.IsTerminator()
 			}
 		}
 
@@ -197,15 +197,35 @@ func UpsertMappedBag(bagMap map[string]TokenBag, bagType, bagId int, forObj stri
 
 	key := bagKey(bagType, bagId)
 
-	_, ok := bagMap[key]
+	// Keep any warnings that have already been found for the bag
+	var warnings []string
+	b, ok := bagMap[key]
 	if ok {
+		warnings = b.warnings
 		delete(bagMap, key)
 	}
 
 	bagMap[key] = TokenBag{
-		id:     bagId,
-		typeOf: bagType,
-		forObj: forObj,
-		tokens: tokens,
+		id:       bagId,
+		typeOf:   bagType,
+		forObj:   forObj,
+		tokens:   tokens,
+		warnings: warnings,
+	}
+}
+
+// AddMappedBagWarnings adds (non-fatal) warnings to the mapped bag
+func AddMappedBagWarnings(bagMap map[string]TokenBag, bagType, bagId int, warnings []string) {
+
+	if len(warnings) == 0 {
+		return
+	}
+
+	key := bagKey(bagType, bagId)
+
+	b, ok := bagMap[key]
+	if ok {
+		b.warnings = append(b.warnings, warnings...)
+		bagMap[key] = b
 	}
 }
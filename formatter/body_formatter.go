@@ -0,0 +1,254 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gsiems/sqlfmt/env"
+	"github.com/gsiems/sqlfmt/parser"
+)
+
+// BodyFormatter formats the body of a PostgreSQL function, procedure, or DO
+// block that is written in a procedural language that sqlfmt does not format
+// itself (PL/Perl, PL/Python, PL/Tcl, etc.).
+//
+// The body is supplied with the common indentation of its lines removed and
+// the formatted body is re-indented by the common indentation before being
+// placed back between the dollar-quote tags. Should the formatter return an
+// error then the original body is kept and the error is reported as a
+// warning.
+type BodyFormatter interface {
+	FormatBody(lang, body string) (string, error)
+}
+
+// BodyFormatterFunc allows an ordinary function to be used as a BodyFormatter
+type BodyFormatterFunc func(lang, body string) (string, error)
+
+func (f BodyFormatterFunc) FormatBody(lang, body string) (string, error) {
+	return f(lang, body)
+}
+
+// CommandFormatter is a BodyFormatter that pipes the body through a local
+// command (such as "black -q -" or "perltidy -st") and uses the output of the
+// command as the formatted body. The command is run directly (not via a
+// shell).
+type CommandFormatter struct {
+	Command string
+	Timeout time.Duration // defaults to 30 seconds
+}
+
+func (c CommandFormatter) FormatBody(lang, body string) (string, error) {
+
+	args := strings.Fields(c.Command)
+	if len(args) == 0 {
+		return "", errors.New("no body formatter command specified")
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(body)
+	cmd.Stdout = &stdout
+
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	ret := stdout.String()
+	if strings.TrimSpace(ret) == "" && strings.TrimSpace(body) != "" {
+		return "", errors.New("body formatter command returned no output")
+	}
+	return ret, nil
+}
+
+var (
+	bodyFormattersMu sync.RWMutex
+	bodyFormatters   = make(map[string]BodyFormatter)
+)
+
+// RegisterBodyFormatter registers the in-process BodyFormatter to use for
+// the specified language (plperl, plpython3u, pltcl, etc.). Registering a
+// nil BodyFormatter removes the registration for the language. In-process
+// formatters take precedence over any formatter commands that are
+// configured for the language.
+func RegisterBodyFormatter(lang string, f BodyFormatter) {
+
+	lang = strings.ToLower(lang)
+
+	bodyFormattersMu.Lock()
+	defer bodyFormattersMu.Unlock()
+
+	if f == nil {
+		delete(bodyFormatters, lang)
+		return
+	}
+	bodyFormatters[lang] = f
+}
+
+// bodyFormatter returns the BodyFormatter (if any) for the language
+func bodyFormatter(e *env.Env, lang string) (BodyFormatter, bool) {
+
+	lang = strings.ToLower(lang)
+
+	bodyFormattersMu.RLock()
+	f, ok := bodyFormatters[lang]
+	bodyFormattersMu.RUnlock()
+
+	if ok {
+		return f, true
+	}
+
+	if cmd, ok := e.BodyFormatterCommand(lang); ok {
+		return CommandFormatter{Command: cmd}, true
+	}
+	return nil, false
+}
+
+// formatPgForeignBodies runs the dollar-quoted bodies of PostgreSQL
+// functions, procedures, and DO blocks that are written in languages other
+// than SQL or PL/pgSQL through the BodyFormatter for the language (if there
+// is one). Any formatter failures are returned as warnings.
+func formatPgForeignBodies(e *env.Env, tokens []FmtToken) []string {
+
+	lang := ""
+	for idx := 0; idx < len(tokens)-1; idx++ {
+		if tokens[idx].AsUpper() == "LANGUAGE" {
+			lang = strings.ToLower(strings.Trim(tokens[idx+1].value, "'\""))
+			break
+		}
+	}
+
+	switch lang {
+	case "", "sql", "plpgsql":
+		return nil
+	}
+
+	f, ok := bodyFormatter(e, lang)
+	if !ok {
+		return nil
+	}
+
+	var warnings []string
+
+	// As with splitPgBodies, the body is the dollar-quoted string that
+	// follows the AS keyword or, for DO blocks, the dollar-quoted string
+	// wherever it is in the statement
+	isDo := false
+	pKwVal := "" // The upper-case value of the previous keyword token
+
+	for idx := 0; idx < len(tokens); idx++ {
+
+		switch {
+		case tokens[idx].typeOf == parser.DollarQuoted:
+			if pKwVal == "AS" || isDo {
+				value, err := formatForeignBody(f, lang, tokens[idx].value)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("Failed to format the %s body: %s", lang, err))
+					continue
+				}
+				tokens[idx].value = value
+			}
+		case tokens[idx].IsKeyword():
+			if tokens[idx].AsUpper() == "DO" {
+				isDo = true
+			}
+		}
+
+		if tokens[idx].IsKeyword() {
+			pKwVal = tokens[idx].AsUpper()
+		}
+	}
+
+	return warnings
+}
+
+// formatForeignBody formats the contents of a dollar-quoted body using the
+// BodyFormatter. A body that is on the same line as its dollar-quote tags
+// stays on one line if the formatted body is a single line. The original body
+// is returned, along with the error, should the formatter fail.
+func formatForeignBody(f BodyFormatter, lang, value string) (string, error) {
+
+	tag := value[:strings.Index(value[1:], "$")+2]
+	if len(value) < len(tag)*2 || !strings.HasSuffix(value, tag) {
+		// Un-terminated string
+		return value, nil
+	}
+
+	inner := value[len(tag) : len(value)-len(tag)]
+	isOneLine := !strings.Contains(strings.TrimSpace(inner), "\n")
+
+	// Any white-space between the opening tag and the text on the same line
+	// isn't indentation
+	trimmed := strings.TrimLeft(inner, " \t")
+	if !strings.HasPrefix(trimmed, "\r") && !strings.HasPrefix(trimmed, "\n") {
+		inner = trimmed
+	}
+	inner = strings.TrimPrefix(strings.TrimPrefix(inner, "\r"), "\n")
+	inner = strings.TrimRight(inner, " \t\r\n")
+
+	lines := strings.Split(inner, "\n")
+	indent := commonIndent(lines)
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, indent)
+	}
+
+	body, err := f.FormatBody(lang, strings.Join(lines, "\n")+"\n")
+	if err != nil {
+		return value, err
+	}
+
+	lines = strings.Split(strings.TrimRight(body, " \t\r\n"), "\n")
+
+	if isOneLine && len(lines) == 1 {
+		return tag + " " + strings.TrimSpace(lines[0]) + " " + tag, nil
+	}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = indent + line
+		}
+	}
+
+	return tag + "\n" + strings.Join(lines, "\n") + "\n" + tag, nil
+}
+
+// commonIndent determines the leading white-space that is common to all of
+// the non-blank lines
+func commonIndent(lines []string) string {
+
+	ret := ""
+	isFirst := true
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if isFirst {
+			ret = lead
+			isFirst = false
+			continue
+		}
+
+		i := 0
+		for i < len(ret) && i < len(lead) && ret[i] == lead[i] {
+			i++
+		}
+		ret = ret[:i]
+	}
+	return ret
+}
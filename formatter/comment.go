@@ -134,48 +134,80 @@ func formatCodeComment(e *env.Env, cTok FmtToken, baseIndents int) FmtToken {
 		rt.indents = baseIndents
 	}
 
-	switch rt.categoryOf {
-	case parser.LineComment, parser.PoundLineComment:
-		// nada
-	case parser.BlockComment:
-
-		/* TODO:
-		 * For comments that have leading vertical space, determine the initial indent and compare it to the indents value.
-		 * If the indent values differ then adjust the indentation of the comment.
-		 * For multiple line comments this will require removing the "initial indent" from each line and prepending the new initial indent
-		 */
-
-		//		lines := strings.Split(cTok.Value(), "\n")
-		//ihSpace := cTok.hSpace
-
-		//switch rt.vSpace {
-		//case 0:
-
-		// use indents to set leading space for each line
-
-		//default:
+	return rt
+}
 
-		// use ihSpace to trim, then set, leading space for each line
+// reindentComment adjusts the indentation of multi-line block comments that
+// start on a new line (both as parsed and as formatted)
+func reindentComment(e *env.Env, cTok FmtToken) FmtToken {
+	if cTok.typeOf == parser.BlockComment && cTok.vSpace > 0 && cTok.vSpaceOrig > 0 {
+		cTok.value = reindentBlockComment(e, cTok.value, cTok.hSpaceOrig, cTok.indents)
+	}
+	return cTok
+}
 
-		//}
+// reindentBlockComment adjusts the indentation of the second and subsequent
+// lines of a multi-line block comment to match the (new) indentation of the
+// first line. The original indentation of the first line is removed from each
+// line and replaced with the new indentation. Any indentation beyond the
+// original is retained (as spaces) so that the relative alignment of the lines
+// (and any box-drawn comment art) is preserved.
+//
+// For determining the original indentation, tabs advance to the next
+// indentSize column, so with 4-spaces per indent:
+//
+//	space space space space => 1 indent
+//	space space space tab => 1 indent
+//	space space tab => 1 indent
+//	space tab => 1 indent
+//	tab => 1 indent
+func reindentBlockComment(e *env.Env, value, hSpaceOrig string, indents int) string {
+
+	lines := strings.Split(value, "\n")
+	if len(lines) < 2 {
+		return value
+	}
 
-		//for idx, line := range lines {
+	tabSize := indentTabSize(e)
 
-		/*
-		   4-spaces per indent:
+	baseCols := indentColumns(hSpaceOrig, tabSize)
+	newIndent := strings.Repeat(e.Indent(), indents)
 
-		   space space space space => 1 indent
-		   space space space tab => 1 indent
-		   space space tab => 1 indent
-		   space tab => 1 indent
-		   tab => 1 indent
+	for idx := 1; idx < len(lines); idx++ {
+		text := strings.TrimLeft(lines[idx], " \t")
+		if strings.TrimSpace(text) == "" {
+			lines[idx] = text
+			continue
+		}
+		lineCols := indentColumns(lines[idx][:len(lines[idx])-len(text)], tabSize)
+		lines[idx] = newIndent + strings.Repeat(" ", max(lineCols-baseCols, 0)) + text
+	}
 
-		*/
+	return strings.Join(lines, "\n")
+}
 
-		//}
+// indentTabSize determines the number of columns that a tab advances to when
+// measuring indentation
+func indentTabSize(e *env.Env) int {
+	if e.Indent() == "\t" {
+		return 4
+	}
+	return len(e.Indent())
+}
 
+// indentColumns determines the number of columns spanned by the leading
+// white-space of a line
+func indentColumns(s string, tabSize int) int {
+	cols := 0
+	for _, r := range s {
+		switch r {
+		case '\t':
+			cols = (cols/tabSize + 1) * tabSize
+		default:
+			cols++
+		}
 	}
-	return rt
+	return cols
 }
 
 func adjustCommentIndents(bagType int, tokens *[]FmtToken) {
@@ -268,3 +300,91 @@ func adjustCommentIndents(bagType int, tokens *[]FmtToken) {
 		}
 	}
 }
+
+// alignComments aligns the trailing line comments of consecutive lines to a
+// common column. The column is determined by the longest line in the run of
+// lines (plus a minimum gap). Comments that would then cause their line to
+// exceed the max line length are placed a single space after the code instead.
+func alignComments(e *env.Env, tokens []FmtToken) []FmtToken {
+
+	const minGap = 2
+
+	type trlLine struct {
+		idx   int // the index of the trailing comment
+		width int // the width of the line up to the comment
+	}
+
+	var run []trlLine
+
+	alignRun := func() {
+		if len(run) > 1 {
+			col := 0
+			for _, l := range run {
+				if l.width+minGap+displayWidth(tokens[l.idx].value) <= e.MaxLineLength() {
+					col = max(col, l.width+minGap)
+				}
+			}
+			for _, l := range run {
+				switch {
+				case col == 0, l.width+minGap > col:
+					tokens[l.idx].hSpace = " "
+				case col+displayWidth(tokens[l.idx].value) > e.MaxLineLength():
+					tokens[l.idx].hSpace = " "
+				default:
+					tokens[l.idx].hSpace = strings.Repeat(" ", col-l.width)
+				}
+			}
+		}
+		run = nil
+	}
+
+	idxMax := len(tokens) - 1
+	idxStart := 0
+	for idx := 0; idx <= idxMax; idx++ {
+
+		if idx < idxMax && tokens[idx+1].vSpace == 0 {
+			continue
+		}
+
+		// idx is the last token of the line that starts at idxStart
+		lineStart := idxStart
+		idxStart = idx + 1
+
+		if tokens[lineStart].vSpace > 1 {
+			alignRun()
+		}
+
+		cTok := tokens[idx]
+		switch {
+		case idx == lineStart:
+			alignRun()
+			continue
+		case cTok.typeOf != parser.LineComment && cTok.typeOf != parser.PoundLineComment:
+			alignRun()
+			continue
+		}
+
+		width := indentColumns(strings.Repeat(e.Indent(), tokens[lineStart].indents), indentTabSize(e))
+		isMultiLine := false
+		for i := lineStart; i < idx; i++ {
+			if strings.Contains(tokens[i].value, "\n") {
+				isMultiLine = true
+				break
+			}
+			if i > lineStart {
+				width += displayWidth(tokens[i].hSpace)
+			}
+			width += displayWidth(tokens[i].value)
+		}
+
+		if isMultiLine {
+			alignRun()
+			continue
+		}
+
+		run = append(run, trlLine{idx: idx, width: width})
+	}
+	alignRun()
+
+	return tokens
+}
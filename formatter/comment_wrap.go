@@ -0,0 +1,333 @@
+package formatter
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gsiems/sqlfmt/env"
+	"github.com/gsiems/sqlfmt/parser"
+)
+
+// cmtLine is one line of a comment split into the parts that are needed for
+// reflowing the comment text
+type cmtLine struct {
+	prefix string // the leading text of the line (indentation, comment marker, gutter)
+	text   string // the comment text
+	suffix string // the closing comment marker (if any)
+	prose  bool   // indicates if the text may be reflowed
+}
+
+var cmtListItem = regexp.MustCompile(`^([-*+•]|\d+[.)]|[a-zA-Z][.)]|\(\w+\))\s`)
+
+// wrapComments reflows the prose of the comments that start a line so that
+// the comments do not exceed the max line length. Only the paragraphs that
+// contain over-long lines are reflowed.
+func wrapComments(e *env.Env, tokens []FmtToken) []FmtToken {
+
+	var ret []FmtToken
+	idxMax := len(tokens) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+		cTok := tokens[idx]
+
+		switch {
+		case cTok.categoryOf != parser.Comment:
+			ret = append(ret, cTok)
+		case idx > 0 && cTok.vSpace == 0:
+			// trailing comments are left as is
+			ret = append(ret, cTok)
+		case cTok.typeOf == parser.BlockComment:
+			cTok.value = wrapBlockComment(e, cTok)
+			ret = append(ret, cTok)
+		default:
+			// gather the consecutive line comments that share an indentation
+			idxEnd := idx + 1
+			for ; idxEnd <= idxMax; idxEnd++ {
+				nTok := tokens[idxEnd]
+				if nTok.categoryOf != parser.Comment || nTok.typeOf != cTok.typeOf {
+					break
+				}
+				if nTok.vSpace != 1 || nTok.indents != cTok.indents {
+					break
+				}
+			}
+			ret = append(ret, wrapLineComments(e, tokens[idx:idxEnd])...)
+			idx = idxEnd - 1
+		}
+	}
+	return ret
+}
+
+// wrapLineComments reflows a block of consecutive line comments
+func wrapLineComments(e *env.Env, tokens []FmtToken) []FmtToken {
+
+	col := indentColumns(strings.Repeat(e.Indent(), tokens[0].indents), indentTabSize(e))
+
+	var lines []cmtLine
+	for _, cTok := range tokens {
+
+		marker := "--"
+		if cTok.typeOf == parser.PoundLineComment {
+			marker = "#"
+		}
+
+		body := strings.TrimPrefix(cTok.value, marker)
+		text := strings.TrimLeft(body, " \t")
+		prefix := marker + body[:len(body)-len(text)]
+
+		lines = append(lines, cmtLine{
+			prefix: prefix,
+			text:   text,
+			prose:  prefix == marker+" " && isCommentProse(text),
+		})
+	}
+
+	if !reflowLines(e, &lines, col, col, "") {
+		return tokens
+	}
+
+	var ret []FmtToken
+	for idx, line := range lines {
+		nt := tokens[0]
+		if idx > 0 {
+			nt.vSpace = 1
+			nt.ledComments = nil
+			nt.trlComments = nil
+		}
+		nt.value = line.prefix + line.text
+		ret = append(ret, nt)
+	}
+	return ret
+}
+
+// wrapBlockComment reflows the prose in a block comment
+func wrapBlockComment(e *env.Env, cTok FmtToken) string {
+
+	switch {
+	case strings.HasPrefix(cTok.value, "/*+"):
+		// Oracle optimizer hint
+		return cTok.value
+	case strings.HasPrefix(cTok.value, "/*!"):
+		// MySQL executable comment
+		return cTok.value
+	}
+
+	indent := strings.Repeat(e.Indent(), cTok.indents)
+
+	var lines []cmtLine
+	for idx, v := range strings.Split(cTok.value, "\n") {
+
+		var line cmtLine
+
+		switch idx {
+		case 0:
+			rest := strings.TrimPrefix(v, "/*")
+			line.text = strings.TrimLeft(rest, " \t")
+			line.prefix = "/*" + rest[:len(rest)-len(line.text)]
+		default:
+			rest := strings.TrimLeft(v, " \t")
+			line.prefix = v[:len(v)-len(rest)]
+			if strings.HasPrefix(rest, "*") && !strings.HasPrefix(rest, "*/") {
+				// "star gutter"
+				rest = rest[1:]
+				line.text = strings.TrimLeft(rest, " \t")
+				line.prefix += "*" + rest[:len(rest)-len(line.text)]
+			} else {
+				line.text = rest
+			}
+		}
+
+		if strings.HasSuffix(line.text, "*/") {
+			t := strings.TrimRight(strings.TrimSuffix(line.text, "*/"), " \t")
+			line.suffix = line.text[len(t):]
+			line.text = t
+		}
+
+		switch {
+		case idx == 0:
+			line.prose = line.prefix == "/* " && isCommentProse(line.text)
+		case strings.HasSuffix(line.prefix, "*"):
+			// nada, there needs to be a space after the gutter
+		case strings.Contains(line.prefix, "*"):
+			line.prose = strings.HasSuffix(line.prefix, "* ") && isCommentProse(line.text)
+		default:
+			line.prose = isCommentProse(line.text)
+		}
+
+		lines = append(lines, line)
+	}
+
+	// continuation lines for the first line align with the text of the first line
+	contPrefix := indent + strings.Repeat(" ", displayWidth(lines[0].prefix))
+
+	if !reflowLines(e, &lines, indentColumns(indent, indentTabSize(e)), 0, contPrefix) {
+		return cTok.value
+	}
+
+	var z []string
+	for _, line := range lines {
+		z = append(z, line.prefix+line.text+line.suffix)
+	}
+	return strings.Join(z, "\n")
+}
+
+// reflowLines reflows the paragraphs (runs of prose lines that share a
+// prefix) that contain lines that exceed the max line length. The col0 and
+// col values are the columns that the first, and subsequent, lines start at
+// (in addition to the width of the line prefix) and contPrefix is the prefix
+// to use when continuing a paragraph that only has a first line. Reports
+// whether any paragraphs were reflowed.
+func reflowLines(e *env.Env, lines *[]cmtLine, col0, col int, contPrefix string) bool {
+
+	startCol := func(i int) int {
+		if i == 0 {
+			return col0
+		}
+		return col
+	}
+
+	var ret []cmtLine
+	isDirty := false
+	idxMax := len(*lines) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+		line := (*lines)[idx]
+
+		if !line.prose {
+			ret = append(ret, line)
+			continue
+		}
+
+		// Determine the extent of the paragraph
+		idxEnd := idx
+		for idxEnd < idxMax && (*lines)[idxEnd].suffix == "" {
+			nLine := (*lines)[idxEnd+1]
+			if !nLine.prose {
+				break
+			}
+			if idxEnd > idx && nLine.prefix != (*lines)[idxEnd].prefix {
+				break
+			}
+			if idx > 0 && nLine.prefix != line.prefix {
+				break
+			}
+			idxEnd++
+		}
+
+		tooLong := false
+		var words []string
+		for i := idx; i <= idxEnd; i++ {
+			l := (*lines)[i]
+			if startCol(i)+commentWidth(l.prefix+l.text+l.suffix) > e.MaxLineLength() {
+				tooLong = true
+			}
+			words = append(words, strings.Fields(l.text)...)
+		}
+
+		if !tooLong {
+			ret = append(ret, (*lines)[idx:idxEnd+1]...)
+			idx = idxEnd
+			continue
+		}
+
+		nPrefix := line.prefix
+		switch {
+		case idxEnd > idx:
+			nPrefix = (*lines)[idx+1].prefix
+		case idx == 0 && contPrefix != "":
+			nPrefix = contPrefix
+		}
+		suffix := (*lines)[idxEnd].suffix
+
+		// Fill the lines
+		nLine := cmtLine{prefix: line.prefix, prose: true}
+		lineCol := startCol(idx) + commentWidth(line.prefix)
+		for i, w := range words {
+			wLen := displayWidth(w)
+			if i == len(words)-1 {
+				wLen += displayWidth(suffix)
+			}
+
+			switch {
+			case nLine.text == "":
+				nLine.text = w
+				lineCol += wLen
+			case lineCol+1+wLen > e.MaxLineLength():
+				ret = append(ret, nLine)
+				nLine = cmtLine{prefix: nPrefix, text: w, prose: true}
+				lineCol = col + commentWidth(nPrefix) + wLen
+			default:
+				nLine.text += " " + w
+				lineCol += 1 + wLen
+			}
+		}
+		nLine.suffix = suffix
+		ret = append(ret, nLine)
+
+		isDirty = true
+		idx = idxEnd
+	}
+
+	if isDirty {
+		*lines = ret
+	}
+	return isDirty
+}
+
+// commentWidth determines the display width of a line of comment text with
+// tabs expanded to 4 columns
+func commentWidth(s string) int {
+	return displayWidth(strings.ReplaceAll(s, "\t", "    "))
+}
+
+// isCommentProse determines if the text of a comment line appears to be
+// prose that may be reflowed rather than code, lists, URLs, tables, rules,
+// box-drawn art, or sqlfmt directives.
+func isCommentProse(text string) bool {
+
+	switch {
+	case text == "":
+		return false
+	case strings.HasPrefix(text, "sqlfmt"):
+		return false
+	case strings.Contains(text, "://"), strings.Contains(text, "www."):
+		return false
+	case cmtListItem.MatchString(text):
+		return false
+	case strings.Contains(text, "|"), strings.Contains(text, "  "), strings.Contains(text, "\t"):
+		// tables, aligned columns, and box-drawn art
+		return false
+	case strings.HasSuffix(text, " *"), strings.HasSuffix(text, " #"):
+		// box-drawn art
+		return false
+	case strings.IndexFunc(text, unicode.IsLetter) < 0:
+		// rules, etc.
+		return false
+	}
+	return !isCommentCode(text)
+}
+
+// isCommentCode determines if the text of a comment line appears to be code
+func isCommentCode(text string) bool {
+
+	switch {
+	case strings.HasSuffix(text, ";"), strings.HasSuffix(text, "{"), strings.HasSuffix(text, "}"):
+		return true
+	}
+
+	for _, op := range []string{":=", "=>", "::", "||", "==", "!=", "<>", " = "} {
+		if strings.Contains(text, op) {
+			return true
+		}
+	}
+
+	switch strings.Fields(text)[0] {
+	case "ALTER", "BEGIN", "CALL", "CREATE", "DECLARE", "DELETE", "DROP",
+		"ELSE", "ELSIF", "END", "EXECUTE", "FROM", "GRANT", "GROUP", "IF",
+		"INSERT", "JOIN", "LOOP", "ORDER", "PERFORM", "RETURN", "REVOKE",
+		"SELECT", "SET", "UNION", "UPDATE", "VALUES", "WHERE", "WITH":
+		return true
+	}
+	return false
+}
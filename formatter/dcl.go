@@ -130,6 +130,8 @@ func formatDCLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 		// Update the type and amount of white-space before the token
 		if cTok.vSpace > 0 {
 			cTok.AdjustIndents(indents)
+			// set the line wrapping break point
+			cTok.fbp = true
 		} else {
 			cTok.AdjustHSpace(e, pTok)
 		}
@@ -155,7 +157,7 @@ func formatDCLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 		tFormatted = append(tFormatted, cTok)
 	}
 
-	tFormatted = wrapOnCommasX(e, DCLBag, 1, tFormatted)
+	tFormatted = wrapLines(e, DCLBag, tFormatted)
 	adjustCommentIndents(bagType, &tFormatted)
 
 	// Replace the mapped tokens with the newly formatted tokens
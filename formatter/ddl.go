@@ -35,6 +35,8 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 	parensDepth := 0
 
 	var pTok FmtToken // The previous token
+	pKwVal := ""      // The upper-case value of the previous keyword token
+	var tsql tsqlStmt // T-SQL statements need not be terminated
 
 	for _, cTok := range m {
 
@@ -67,13 +69,27 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 				}
 			default:
 				switch {
+				case cTok.IsDMLBag() && e.Dialect() == dialect.MSSQL:
+					closeBag = true
+					// unless it is the query for a view, etc. it is the
+					// start of the next (unterminated) T-SQL statement
+					addToBag = pTok.AsUpper() == "AS"
 				case cTok.IsDMLBag(), cTok.IsPLBag():
 					closeBag = true
-				case ctVal == ";":
+				case parensDepth == 0 && e.Dialect() == dialect.MSSQL && isTSQLDDLBoundary(&tsql, pTok, ctVal):
+					closeBag = true
+					addToBag = false
+				case cTok.IsTerminator():
+					closeBag = true
+				case parensDepth == 0 && isOraSlashTerminator(e, cTok):
 					closeBag = true
 				}
 			}
 
+			if !closeBag && e.Dialect() == dialect.MSSQL {
+				tsql.track(ctVal, pKwVal)
+			}
+
 		case false:
 			// Consider the previous token data to determine if a bag could be opened
 
@@ -86,7 +102,7 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 			case "/":
 				canOpenBag = e.Dialect() == dialect.Oracle
 			default:
-				canOpenBag = pTok.IsBag()
+				canOpenBag = pTok.IsBag() || pTok.IsData() || pTok.IsTerminator()
 			}
 		}
 
@@ -100,6 +116,8 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 				openBag = true
 			case "IMPORT":
 				openBag = e.Dialect() == dialect.PostgreSQL
+			case "ATTACH", "DETACH", "PRAGMA", "VACUUM":
+				openBag = e.Dialect() == dialect.SQLite
 			}
 		}
 
@@ -110,6 +128,8 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 
 			if addToBag {
 				bagTokens = append(bagTokens, cTok)
+			} else {
+				remainder = append(remainder, cTok)
 			}
 
 			// Close the bag
@@ -138,6 +158,8 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 			bagTokens = nil
 			//bagTokens = []FmtToken{cTok}
 			bagTokens = append(bagTokens, cTok)
+			tsql = tsqlStmt{}
+			tsql.track(ctVal, pKwVal)
 
 			// Add a token that has the pointer to the new bag
 			remainder = append(remainder, FmtToken{
@@ -159,6 +181,9 @@ func tagDDLV0(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 		////////////////////////////////////////////////////////////////
 		// Cache the previous token(s) data
 		pTok = cTok
+		if cTok.IsKeyword() {
+			pKwVal = ctVal
+		}
 	}
 
 	// On the off chance that the bag wasn't closed properly (incomplete or
@@ -188,6 +213,12 @@ func ddlObjType(e *env.Env, tokens []FmtToken) string {
 
 		switch ctVal {
 
+		case "ATTACH", "DETACH", "PRAGMA", "VACUUM":
+			// SQLite database and pragma statements
+			if idx == 0 && e.Dialect() == dialect.SQLite {
+				return ctVal
+			}
+
 		case "ACCESS", "EVENT", "FOREIGN", "LARGE", "MATERIALIZED",
 			"OPERATOR", "TRANSFORM":
 
@@ -238,6 +269,7 @@ func formatDDLKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 
 	objType := ddlObjType(e, tokens)
 	parensDepth := 0
+	var pTok FmtToken // The previous token
 
 	for _, cTok := range tokens {
 
@@ -258,6 +290,11 @@ func formatDDLKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 				"UPDATE", "USING", "VALIDATE", "VALUES", "WHERE", "WITH":
 
 				cTok.SetUpper()
+			case "ROWID", "STRICT":
+				// SQLite table options
+				if parensDepth == 0 && e.Dialect() == dialect.SQLite {
+					cTok.SetUpper()
+				}
 			}
 
 		case "DATABASE":
@@ -275,6 +312,12 @@ func formatDDLKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 				}
 			}
 
+		case "PRAGMA":
+			// pragma values (WAL, NORMAL, OFF, etc.) are keywords
+			if pTok.value == "=" && cTok.IsIdentifier() {
+				cTok.SetUpper()
+			}
+
 		case "RULE":
 			switch ctVal {
 			case "ALSO", "INSTEAD", "NOTHING":
@@ -321,6 +364,7 @@ func formatDDLKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 		}
 
 		ret = append(ret, cTok)
+		pTok = cTok
 	}
 
 	return ret
@@ -351,6 +395,8 @@ func formatDDLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 
 	var tFormatted []FmtToken
 	var pTok FmtToken // The previous token
+	pKwVal := ""      // The upper-case value of the previous keyword token
+	genDepth := -1    // The parens depth of a generated column clause that starts a line
 
 	for idx := 0; idx <= idxMax; idx++ {
 
@@ -446,10 +492,22 @@ func formatDDLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 			default:
 				indents++
 			}
+			// continuation lines that lead with an operator are indented
+			// further than the line they continue
+			if isOperator(0, cTok) || isLogical(pKwVal, cTok) {
+				indents++
+			}
+			// as are generated column clauses (and, when the clause starts
+			// a line, the lines of the expression)
+			if ctVal == "GENERATED" || (genDepth >= 0 && parensDepth > genDepth) {
+				indents++
+			}
 		}
 
 		if cTok.vSpace > 0 {
 			cTok.AdjustIndents(indents)
+			// set the line wrapping break point
+			cTok.fbp = true
 		} else {
 			cTok.AdjustHSpace(e, pTok)
 		}
@@ -469,8 +527,18 @@ func formatDDLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 			parensDepth--
 		}
 
+		switch {
+		case ctVal == "GENERATED" && cTok.vSpace > 0:
+			genDepth = parensDepth
+		case genDepth >= 0 && parensDepth == genDepth && (ctVal == "," || ctVal == ")"):
+			genDepth = -1
+		}
+
 		// Set the various "previous token" values
 		pTok = cTok
+		if cTok.IsKeyword() {
+			pKwVal = ctVal
+		}
 
 		addTok := true
 		if parensDepth > 0 {
@@ -478,8 +546,11 @@ func formatDDLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 			case "IN", "INOUT", "OUT":
 				switch e.Dialect() {
 				case dialect.PostgreSQL:
-					// not needed for setting ownership
-					addTok = false
+					switch objType {
+					case "AGGREGATE", "FUNCTION", "PROCEDURE", "ROUTINE":
+						// not needed for setting ownership
+						addTok = false
+					}
 				}
 			}
 		}
@@ -512,6 +583,8 @@ func formatDDLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 			pTok = tFormatted[i]
 		}
 		tFormatted = wrapOnCommasX(e, DDLBag, 1, tFormatted)
+	} else {
+		tFormatted = wrapLines(e, DDLBag, tFormatted)
 	}
 
 	adjustCommentIndents(bagType, &tFormatted)
@@ -101,6 +101,8 @@ func tagDML(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 	parensDepth := 0
 	pKwVal := ""      // The upper-case value of the previous keyword token
 	var pTok FmtToken // The previous token
+	isForall := false // Oracle FORALL statements contain a single DML statement
+	var tsql tsqlStmt // T-SQL statements need not be terminated
 
 	for _, cTok := range m {
 
@@ -157,7 +159,31 @@ func tagDML(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 					canOpenChildBag = true
 				}
 
-				if pTok.IsBag() {
+				if cTok.IsTerminator() {
+					// MySQL/MariaDB DELIMITER terminators
+					closeBag = true
+					addToMap = true
+				} else if parensDepth == 0 && isOraSlashTerminator(e, cTok) {
+					// Oracle scripts may terminate statements with a "/"
+					// on a line of its own rather than a ";"
+					closeBag = true
+					addToMap = true
+				} else if parensDepth == 0 && cTok.IsData() {
+					// client commands (DELIMITER, psql, SQL*Plus) are not
+					// part of the statement
+					closeBag = true
+				} else if parensDepth == 0 && e.Dialect() == dialect.MSSQL && tsql.isBoundary(pTok, ctVal) {
+					// the start of the next (unterminated) T-SQL statement,
+					// which may itself be DML, so the bag is closed here
+					// rather than after the token is processed
+					isInBag = false
+					bagId = 0
+					for id, _ := range bagIds {
+						delete(bagIds, id)
+					}
+					parensDepth = 0
+					canOpenBag = true
+				} else if pTok.IsBag() {
 					closeBag = true
 				} else {
 					switch e.Dialect() {
@@ -184,6 +210,13 @@ func tagDML(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 				canOpenBag = e.Dialect() == dialect.PostgreSQL
 			case "/":
 				canOpenBag = e.Dialect() == dialect.Oracle
+			case "IS":
+				// Oracle cursor declarations
+				canOpenBag = e.Dialect() == dialect.Oracle
+			case "FOR", "DO", "REPEAT", "ROW":
+				// MySQL/MariaDB cursor declarations, loop bodies, and
+				// single statement trigger/event bodies
+				canOpenBag = isMySQLish(e)
 			default:
 				if e.Dialect() == dialect.PostgreSQL && isPgBodyBoundary(pTok.value) {
 					canOpenBag = true
@@ -191,11 +224,31 @@ func tagDML(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 					//if pTok.IsBag() {
 					//	canOpenBag = true
 					//}
-					canOpenBag = pTok.IsBag()
+					canOpenBag = pTok.IsBag() || pTok.IsData() || pTok.IsTerminator()
 				}
 			}
 		}
 
+		if e.Dialect() == dialect.MSSQL {
+			switch {
+			case isInBag:
+				tsql.track(ctVal, pKwVal)
+			case !canOpenBag:
+				canOpenBag = canOpenTSQLBag(pTok, pKwVal, ctVal)
+			}
+		}
+
+		if !isInBag && e.Dialect() == dialect.Oracle {
+			switch ctVal {
+			case "FORALL":
+				isForall = true
+			case ";":
+				isForall = false
+			case "DELETE", "INSERT", "MERGE INTO", "UPDATE":
+				canOpenBag = canOpenBag || isForall
+			}
+		}
+
 		////////////////////////////////////////////////////////////////
 		// If it is possible to maybe open a bag for either a DML query or for
 		// a sub-query, determine if a bag should be opened
@@ -235,6 +288,7 @@ func tagDML(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 		case openBag:
 			// Open the initial new bag
 			isInBag = true
+			isForall = false
 			bagId = cTok.id
 
 			// Add a token that has the pointer to the new bag...
@@ -253,6 +307,8 @@ func tagDML(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 			bagIds[0] = bagId
 			tokMap[bagId] = []FmtToken{cTok}
 			parensDepth = 0
+			tsql = tsqlStmt{}
+			tsql.track(ctVal, pKwVal)
 
 		case openChildBag:
 
@@ -447,6 +503,17 @@ func formatDMLKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 			switch ctVal {
 			case "REPLACE":
 				cTok.SetUpper()
+			case "ABORT", "FAIL", "IGNORE", "RAISE", "ROLLBACK":
+				// RAISE() functions and conflict resolution
+				if cTok.IsKeyword() {
+					cTok.SetUpper()
+				}
+			}
+		case dialect.MSSQL:
+			switch ctVal {
+			case "EXEC", "EXECUTE":
+				// INSERT INTO ... EXEC ...
+				cTok.SetUpper()
 			}
 		case dialect.Oracle:
 			switch ctVal {
@@ -878,7 +945,7 @@ func formatDMLBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 					switch {
 					case onConflict:
 						switch ctVal {
-						case "ON CONFLICT":
+						case "ON CONFLICT", "RETURNING":
 							localIndents = 1
 						case "UPDATE", "DELETE":
 							localIndents = 2
@@ -2,6 +2,8 @@ package formatter
 
 import (
 	"fmt"
+	"io"
+	"regexp"
 	"strings"
 
 	"github.com/gsiems/db-dialect/dialect"
@@ -22,7 +24,7 @@ const (
 	CommentOnBag              // A bag of "COMMENT ON ..." tokens
 )
 
-func tagBags(e *env.Env, m []FmtToken) (map[string]TokenBag, []FmtToken, []string, []string) {
+func tagBags(e *env.Env, m []FmtToken) (map[string]TokenBag, []FmtToken, []string) {
 
 	bagMap := make(map[string]TokenBag)
 
@@ -33,20 +35,17 @@ func tagBags(e *env.Env, m []FmtToken) (map[string]TokenBag, []FmtToken, []strin
 	// TODO: for now at least. need to revisit once other DBs (especially
 	// Oracle) are better sorted
 	switch e.Dialect() {
-	case dialect.PostgreSQL, dialect.SQLite, dialect.Oracle:
+	case dialect.PostgreSQL, dialect.SQLite, dialect.Oracle, dialect.MySQL, dialect.MariaDB, dialect.MSSQL:
 		remainder = tagPLx(e, remainder, bagMap)
 	}
 	remainder = tagDDL(e, remainder, bagMap)
 
-	// Check for warnings and errors
-	var warnStrings []string // list of (non-fatal) warnings found
-	var errStrings []string  // list of (fatal) errors found
+	// Check for errors. Warnings are checked for once the bags have been
+	// formatted (see bagWarnings)
+	var errStrings []string // list of (fatal) errors found
 
 	for _, bag := range bagMap {
 
-		if len(bag.warnings) > 0 {
-			warnStrings = append(warnStrings, bag.warnings...)
-		}
 		if len(bag.errors) > 0 {
 			errStrings = append(errStrings, bag.errors...)
 		}
@@ -91,7 +90,22 @@ func tagBags(e *env.Env, m []FmtToken) (map[string]TokenBag, []FmtToken, []strin
 		}
 	}
 
-	return bagMap, remainder, warnStrings, errStrings
+	return bagMap, remainder, errStrings
+}
+
+// bagWarnings returns the (non-fatal) warnings found while tagging and
+// formatting the bags
+func bagWarnings(bagMap map[string]TokenBag) []string {
+
+	var warnStrings []string
+
+	for _, bag := range bagMap {
+		if len(bag.warnings) > 0 {
+			warnStrings = append(warnStrings, bag.warnings...)
+		}
+	}
+
+	return warnStrings
 }
 
 func FormatInput(e *env.Env, input string) (string, []string, []string) {
@@ -108,13 +122,15 @@ func FormatInput(e *env.Env, input string) (string, []string, []string) {
 	}
 
 	cleaned := prepParsed(e, parsed)
-	bagMap, mainTokens, warnStrings, errStrings = tagBags(e, cleaned)
+	bagMap, mainTokens, errStrings = tagBags(e, cleaned)
 
 	if len(errStrings) > 0 {
 		return "", warnStrings, errStrings
 	}
 
 	fmtTokens := formatBags(e, mainTokens, bagMap)
+	warnStrings = bagWarnings(bagMap)
+	fmtTokens = terminateStatements(e, fmtTokens, bagMap)
 	untagged := untagBags(fmtTokens, bagMap)
 	unstashed := unstashComments(e, untagged)
 	fmtStatement := combineTokens(e, unstashed)
@@ -122,6 +138,76 @@ func FormatInput(e *env.Env, input string) (string, []string, []string) {
 	return fmtStatement, warnStrings, errStrings
 }
 
+// FormatStream reads the input from the supplied reader, formats it one
+// top-level statement at a time, and writes the formatted statements to the
+// supplied writer. Memory use is bounded by the size of the largest
+// statement rather than by the size of the input (COPY data is written as it
+// is read).
+func FormatStream(e *env.Env, r io.Reader, w io.Writer) ([]string, []string) {
+	var warnStrings []string
+	var errStrings []string
+
+	sr := parser.NewStatementReader(e.DialectName(), r)
+
+	isFirst := true
+	for {
+		chunk, err := sr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errStrings = append(errStrings, fmt.Sprintf("%s", err))
+			return warnStrings, errStrings
+		}
+
+		if sr.IsCopyData() {
+			// COPY data is passed through as is
+			if _, err = io.WriteString(w, chunk); err != nil {
+				errStrings = append(errStrings, fmt.Sprintf("%s", err))
+				return warnStrings, errStrings
+			}
+			continue
+		}
+
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+
+		formatted, ws, es := FormatInput(e, chunk)
+		warnStrings = append(warnStrings, ws...)
+		if len(es) > 0 {
+			errStrings = append(errStrings, es...)
+			return warnStrings, errStrings
+		}
+
+		if !isFirst {
+			formatted = strings.Repeat("\n", chunkBlankLines(e, chunk)) + formatted
+		}
+		isFirst = false
+
+		if _, err = io.WriteString(w, formatted); err != nil {
+			errStrings = append(errStrings, fmt.Sprintf("%s", err))
+			return warnStrings, errStrings
+		}
+	}
+
+	return warnStrings, errStrings
+}
+
+// chunkBlankLines determines the number of blank lines to place before a
+// chunk of streamed input based on the blankLinesBetweenStatements and
+// maxBlankLines settings and the number of blank lines that originally
+// preceded the chunk
+func chunkBlankLines(e *env.Env, chunk string) int {
+
+	if bl := e.BlankLinesBetweenStatements(); bl >= 0 {
+		return bl
+	}
+
+	lead := chunk[:len(chunk)-len(strings.TrimLeft(chunk, " \t\r\n"))]
+	return min(strings.Count(lead, "\n"), e.MaxBlankLines())
+}
+
 // stashComments caches comments with their adjoining non-comment token for the
 // purpose of simplifying formatting logic. (also translates parser tokens to
 // formatting tokens)
@@ -154,12 +240,12 @@ func stashComments(e *env.Env, tokens []parser.Token) []FmtToken {
 		case parser.LineComment, parser.PoundLineComment, parser.BlockComment:
 
 			nt := CmtToken{
-				typeOf: cTok.Type(),
-				value:  strings.TrimRight(cTok.Value(), "\n\r\t "),
-				vSpace: vSpace,
-				hSpace: hSpace,
-				//vSpaceOrig: cTok.VSpace()
-				//hSpaceOrig  cTok.HSpace()
+				typeOf:     cTok.Type(),
+				value:      strings.TrimRight(cTok.Value(), "\n\r\t "),
+				vSpace:     vSpace,
+				hSpace:     hSpace,
+				vSpaceOrig: cTok.VSpace(),
+				hSpaceOrig: cTok.HSpace(),
 			}
 
 			// If the comment has no vertical space and is not the first token
@@ -236,8 +322,8 @@ func stashComments(e *env.Env, tokens []parser.Token) []FmtToken {
 					value:      ct.value,
 					vSpace:     ct.vSpace,
 					hSpace:     ct.hSpace,
-					//vSpaceOrig: cTok.VSpace(),
-					//hSpaceOrig: cTok.HSpace(),
+					vSpaceOrig: ct.vSpaceOrig,
+					hSpaceOrig: ct.hSpaceOrig,
 				}
 				ret = append(ret, nt)
 			}
@@ -272,7 +358,7 @@ func consolidateMWTokens(e *env.Env, tokens []FmtToken) []FmtToken {
 			switch cTok.AsUpper() {
 			case "END":
 				switch tokens[idx+1].AsUpper() {
-				case "IF", "CASE", "LOOP":
+				case "IF", "CASE", "LOOP", "WHILE", "REPEAT":
 					combineNext = true
 				}
 			case "GROUP", "ORDER", "PARTITION", "CONNECT", "INDEX":
@@ -470,7 +556,8 @@ func prepParsed(e *env.Env, parsed []parser.Token) (ret []FmtToken) {
 	// 4. Perform case folding of identifiers, datatypes, and keywords as
 	//      specified in the env
 
-	p1 := stashComments(e, parsed)
+	p0 := splitPgBodies(e, parsed)
+	p1 := stashComments(e, p0)
 	p2 := consolidateDatatypes(e, p1)
 	p3 := consolidateMWTokens(e, p2)
 
@@ -537,6 +624,8 @@ func prepParsed(e *env.Env, parsed []parser.Token) (ret []FmtToken) {
 		switch tType {
 		case parser.Identifier, parser.Datatype, parser.Keyword:
 			tText = strings.ToLower(tText)
+		case parser.UnicodeString, parser.UnicodeIdentifier:
+			tText = formatUEscape(e, tText)
 		}
 
 		cTok.id = idx
@@ -549,19 +638,50 @@ func prepParsed(e *env.Env, parsed []parser.Token) (ret []FmtToken) {
 	return ret
 }
 
+// pgUEscape matches a Unicode escaped string or identifier that has a UESCAPE
+// clause
+var pgUEscape = regexp.MustCompile(`(?is)^(U&(?:'.*'|".*"))\s*(UESCAPE)\s*('.*')$`)
+
+// formatUEscape normalizes the white-space and keyword case of the UESCAPE
+// clause (if any) of a Unicode escaped string or identifier. The quoted
+// portion is left as is.
+func formatUEscape(e *env.Env, s string) string {
+
+	sm := pgUEscape.FindStringSubmatch(s)
+	if sm == nil {
+		return s
+	}
+
+	kw := sm[2]
+	switch e.KeywordCase() {
+	case env.UpperCase:
+		kw = strings.ToUpper(kw)
+	case env.LowerCase:
+		kw = strings.ToLower(kw)
+	}
+	return sm[1] + " " + kw + " " + sm[3]
+}
+
 func formatBags(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 
 	// remember that bagMap is a pointer to the map, not a copy of the map
 
 	var mainTokens []FmtToken
 	parensDepth := 0
+	psqlDepth := 0    // The nesting depth of psql \if ... \endif blocks
 	var pTok FmtToken // The previous token
 
-	for _, cTok := range m {
+	for idx, cTok := range m {
+
+		isStmtStart := idx > 0 && parensDepth == 0 && isStatementStart(e, pTok, cTok)
+		indents := psqlIndents(e, cTok, &psqlDepth)
 
 		switch {
 		case cTok.IsBag():
-			formatBag(e, bagMap, cTok.typeOf, cTok.id, parensDepth, false)
+			formatBag(e, bagMap, cTok.typeOf, cTok.id, parensDepth+indents, false)
+			if isStmtStart {
+				setBagStatementVSpace(e, bagMap, cTok.typeOf, cTok.id)
+			}
 		default:
 			switch parensDepth {
 			case 0:
@@ -580,6 +700,13 @@ func formatBags(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken
 			if cTok.vSpace == 0 {
 				cTok.AdjustHSpace(e, pTok)
 			}
+			limitTokenVSpace(e, &cTok)
+			if isStmtStart {
+				setStatementVSpace(e, &cTok)
+			}
+			if indents > 0 {
+				cTok.AdjustIndents(indents)
+			}
 		}
 
 		pTok = cTok
@@ -625,7 +752,108 @@ func formatBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInden
 		case DNFBag:
 			// nada
 		}
+
+		limitBagVSpace(e, bagMap, key)
+	}
+}
+
+// isOraSlashTerminator returns true if the token is an Oracle "/" that is on
+// a line of its own (as opposed to a division operator)
+func isOraSlashTerminator(e *env.Env, cTok FmtToken) bool {
+	return e.Dialect() == dialect.Oracle && cTok.value == "/" && cTok.vSpace > 0
+}
+
+// isStatementStart determines if the current token is the first token of a
+// new top-level statement
+func isStatementStart(e *env.Env, pTok, cTok FmtToken) bool {
+
+	if cTok.IsTerminator() || cTok.value == "/" {
+		return false
+	}
+
+	switch {
+	case pTok.IsTerminator():
+		return true
+	case pTok.value == "/":
+		return e.Dialect() == dialect.Oracle
+	case pTok.categoryOf == parser.Data:
+		// Runs of consecutive psql commands, etc. are left as they are
+		return cTok.categoryOf != parser.Data
+	case pTok.IsBag():
+		return true
+	}
+	return false
+}
+
+// setStatementVSpace sets the number of blank lines preceding a top-level
+// statement per the blankLinesBetweenStatements setting. Any leading comments
+// are considered to be a part of the statement.
+func setStatementVSpace(e *env.Env, t *FmtToken) {
+
+	bl := e.BlankLinesBetweenStatements()
+	if bl < 0 {
+		return
+	}
+
+	if len(t.ledComments) > 0 {
+		t.ledComments[0].vSpace = bl + 1
+		t.ledComments[0].hSpace = ""
+		return
+	}
+
+	t.vSpace = bl + 1
+	t.hSpace = ""
+}
+
+// setBagStatementVSpace sets the number of blank lines preceding the first
+// token of the specified bag (descending into any nested bag that the first
+// token may point to)
+func setBagStatementVSpace(e *env.Env, bagMap map[string]TokenBag, bagType, bagId int) {
+
+	key := bagKey(bagType, bagId)
+
+	b, ok := bagMap[key]
+	if !ok || len(b.tokens) == 0 {
+		return
+	}
+
+	if b.tokens[0].IsBag() {
+		setBagStatementVSpace(e, bagMap, b.tokens[0].typeOf, b.tokens[0].id)
+		return
+	}
+
+	setStatementVSpace(e, &b.tokens[0])
+	bagMap[key] = b
+}
+
+// limitTokenVSpace applies the maxBlankLines setting to a token and to any
+// comments that are attached to the token
+func limitTokenVSpace(e *env.Env, t *FmtToken) {
+
+	mbl := e.MaxBlankLines()
+
+	t.LimitVSpace(mbl)
+	for j := range t.ledComments {
+		t.ledComments[j].LimitVSpace(mbl)
+	}
+	for j := range t.trlComments {
+		t.trlComments[j].LimitVSpace(mbl)
+	}
+}
+
+// limitBagVSpace applies the maxBlankLines setting to the tokens of a
+// formatted bag
+func limitBagVSpace(e *env.Env, bagMap map[string]TokenBag, key string) {
+
+	b, ok := bagMap[key]
+	if !ok {
+		return
 	}
+
+	for idx := range b.tokens {
+		limitTokenVSpace(e, &b.tokens[idx])
+	}
+	bagMap[key] = b
 }
 
 func commentsToTokens(toks []CmtToken) []FmtToken {
@@ -687,6 +915,274 @@ func untagBags(m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 	return ret
 }
 
+// terminateStatements ensures that each top-level statement is terminated
+// with the terminator that is appropriate for the dialect and that the
+// white-space preceding the terminators is consistent. Only the top-level
+// tokens and non-PL body bags are modified-- PL bodies are left as is.
+func terminateStatements(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
+
+	if !e.TerminateStatements() {
+		return m
+	}
+
+	var ret []FmtToken
+
+	needsTerm := false  // the current statement has not been terminated
+	needsSlash := false // the previous statement was Oracle PL that needs a trailing "/"
+	delim := ";"        // the current MySQL/MariaDB statement delimiter
+
+	for _, cTok := range m {
+
+		isSlash := e.Dialect() == dialect.Oracle && cTok.value == "/"
+
+		if needsSlash && !isSlash {
+			ret = append(ret, FmtToken{
+				categoryOf: parser.Other,
+				typeOf:     parser.Operator,
+				value:      "/",
+				vSpace:     1,
+			})
+		}
+		needsSlash = false
+
+		if needsTerm && isPsqlTerminator(e, cTok) {
+			// the "\g" family of psql commands take the place of the
+			// terminator
+			needsTerm = false
+		}
+
+		if needsTerm && (cTok.IsBag() || cTok.categoryOf == parser.Data) {
+			ret = appendTerminator(e, bagMap, ret, delim)
+			needsTerm = false
+		}
+
+		if d, ok := mySQLDelimiter(e, cTok); ok {
+			delim = d
+		}
+
+		switch {
+		case cTok.IsBag():
+			lTok, isPL := lastBagToken(bagMap, cTok)
+			switch {
+			case isPL && e.Dialect() == dialect.Oracle:
+				needsSlash = true
+			case isPL && e.Dialect() == dialect.MSSQL:
+				// T-SQL batches are terminated by GO
+				needsTerm = false
+			default:
+				switch {
+				case lTok.IsTerminator():
+					needsTerm = false
+				case lTok.value == "/":
+					needsTerm = e.Dialect() != dialect.Oracle
+				default:
+					needsTerm = true
+				}
+			}
+			normalizeTerminatorSpace(e, bagMap, cTok.typeOf, cTok.id)
+		case cTok.categoryOf == parser.Data, cTok.categoryOf == parser.Comment:
+			// nada
+		case cTok.IsTerminator(), isSlash:
+			needsTerm = false
+		default:
+			needsTerm = true
+		}
+
+		ret = append(ret, cTok)
+	}
+
+	if needsTerm {
+		ret = appendTerminator(e, bagMap, ret, delim)
+	}
+	if needsSlash {
+		ret = append(ret, FmtToken{
+			categoryOf: parser.Other,
+			typeOf:     parser.Operator,
+			value:      "/",
+			vSpace:     1,
+		})
+	}
+
+	adjustTerminatorSpace(e, bagMap, ret)
+
+	return ret
+}
+
+// mySQLDelimiter returns the statement delimiter set by a MySQL/MariaDB
+// DELIMITER command and whether or not the token is such a command
+func mySQLDelimiter(e *env.Env, cTok FmtToken) (string, bool) {
+
+	if !isMySQLish(e) || !cTok.IsData() {
+		return "", false
+	}
+
+	args := strings.Fields(cTok.value)
+	if len(args) < 2 || strings.ToUpper(args[0]) != "DELIMITER" {
+		return "", false
+	}
+	return args[1], true
+}
+
+// psqlCommand returns the (lower-cased) name of the psql backslash command
+// for the token, if the token is a psql command
+func psqlCommand(e *env.Env, cTok FmtToken) string {
+
+	if e.Dialect() != dialect.PostgreSQL || !cTok.IsData() || !strings.HasPrefix(cTok.value, "\\") {
+		return ""
+	}
+
+	args := strings.Fields(cTok.value)
+	return strings.ToLower(args[0])
+}
+
+// isPsqlTerminator returns true if the token is a psql command that sends the
+// current query buffer to the server (and is therefore used in place of the
+// semi-colon)
+func isPsqlTerminator(e *env.Env, cTok FmtToken) bool {
+	switch psqlCommand(e, cTok) {
+	case "\\g", "\\gx", "\\gset", "\\gexec", "\\gdesc", "\\crosstabview", "\\watch":
+		return true
+	}
+	return false
+}
+
+// psqlIndents updates the psql conditional (\if ... \endif) nesting depth
+// for the token and returns the number of indents to use for the token
+func psqlIndents(e *env.Env, cTok FmtToken, depth *int) int {
+
+	switch psqlCommand(e, cTok) {
+	case "\\if":
+		*depth++
+		return *depth - 1
+	case "\\elif", "\\else":
+		return max(*depth-1, 0)
+	case "\\endif":
+		*depth = max(*depth-1, 0)
+		return *depth
+	}
+	return *depth
+}
+
+// lastBagToken returns the last token of a bag, descending into any nested
+// bags, and whether or not a PL bag was encountered along the way
+func lastBagToken(bagMap map[string]TokenBag, cTok FmtToken) (FmtToken, bool) {
+
+	isPL := cTok.IsPLBag()
+
+	for cTok.IsBag() {
+		b, ok := bagMap[bagKey(cTok.typeOf, cTok.id)]
+		if !ok || len(b.tokens) == 0 {
+			return cTok, isPL
+		}
+		cTok = b.tokens[len(b.tokens)-1]
+		if cTok.IsPLBag() {
+			isPL = true
+		}
+	}
+	return cTok, isPL
+}
+
+// appendTerminator appends the terminator (normally a semi-colon) to the end of
+// the last statement in the token list. If the last token is a bag then the
+// terminator is appended to the bag (or nested bag) that the last token points
+// to, stopping short of PL bodies and do-not-format bags.
+func appendTerminator(e *env.Env, bagMap map[string]TokenBag, m []FmtToken, delim string) []FmtToken {
+
+	if len(m) == 0 {
+		return m
+	}
+
+	lTok := m[len(m)-1]
+
+	if lTok.IsBag() {
+		key := bagKey(lTok.typeOf, lTok.id)
+		b, ok := bagMap[key]
+		if ok && len(b.tokens) > 0 {
+			switch lTok.typeOf {
+			case PLxBody, DNFBag:
+				// nada
+			default:
+				b.tokens = appendTerminator(e, bagMap, b.tokens, delim)
+				bagMap[key] = b
+				return m
+			}
+		}
+	}
+
+	nt := FmtToken{
+		categoryOf: parser.Punctuation,
+		typeOf:     parser.SemiColon,
+		value:      delim,
+		hSpace:     terminatorHSpace(e),
+	}
+
+	// Any trailing comments on the preceding token need to follow the new
+	// terminator, else the terminator would end up commented out
+	if lTok.HasTrailingComments() {
+		nt.trlComments = lTok.trlComments
+		m[len(m)-1].trlComments = nil
+	} else if pTok, _ := lastBagToken(bagMap, lTok); pTok.HasTrailingComments() {
+		nt.vSpace = 1
+		nt.hSpace = ""
+	}
+
+	return append(m, nt)
+}
+
+// normalizeTerminatorSpace adjusts the white-space preceding the semi-colons
+// in a bag (and any nested bags other than PL bodies and do-not-format bags)
+func normalizeTerminatorSpace(e *env.Env, bagMap map[string]TokenBag, bagType, bagId int) {
+
+	switch bagType {
+	case PLxBody, DNFBag:
+		return
+	}
+
+	key := bagKey(bagType, bagId)
+	b, ok := bagMap[key]
+	if !ok {
+		return
+	}
+
+	for _, cTok := range b.tokens {
+		if cTok.IsBag() {
+			normalizeTerminatorSpace(e, bagMap, cTok.typeOf, cTok.id)
+		}
+	}
+
+	adjustTerminatorSpace(e, bagMap, b.tokens)
+	bagMap[key] = b
+}
+
+// adjustTerminatorSpace ensures that semi-colons are preceded by a single
+// space unless there is a comment in the way and that Oracle "/" terminators
+// are on a line of their own
+func adjustTerminatorSpace(e *env.Env, bagMap map[string]TokenBag, tokens []FmtToken) {
+
+	for idx := 1; idx < len(tokens); idx++ {
+
+		if e.Dialect() == dialect.Oracle && tokens[idx].value == "/" && idx == len(tokens)-1 {
+			tokens[idx].vSpace = 1
+			tokens[idx].indents = 0
+			tokens[idx].hSpace = ""
+			continue
+		}
+
+		if !tokens[idx].IsTerminator() || tokens[idx].HasLeadingComments() {
+			continue
+		}
+
+		pTok, _ := lastBagToken(bagMap, tokens[idx-1])
+		if tokens[idx-1].HasTrailingComments() || pTok.HasTrailingComments() {
+			continue
+		}
+
+		tokens[idx].vSpace = 0
+		tokens[idx].indents = 0
+		tokens[idx].hSpace = terminatorHSpace(e)
+	}
+}
+
 func unstashComments(e *env.Env, tokens []FmtToken) []FmtToken {
 
 	var ret []FmtToken
@@ -704,12 +1200,17 @@ func unstashComments(e *env.Env, tokens []FmtToken) []FmtToken {
 					vSpace:     ct.vSpace,
 					hSpace:     ct.hSpace,
 					indents:    ct.indents,
+					vSpaceOrig: ct.vSpaceOrig,
+					hSpaceOrig: ct.hSpaceOrig,
 				}
-				ret = append(ret, nt)
+				ret = append(ret, reindentComment(e, nt))
 			}
 			cTok.ledComments = nil
 		}
 
+		if cTok.categoryOf == parser.Comment {
+			cTok = reindentComment(e, cTok)
+		}
 		ret = append(ret, cTok)
 
 		if len(cTok.trlComments) > 0 {
@@ -725,13 +1226,22 @@ func unstashComments(e *env.Env, tokens []FmtToken) []FmtToken {
 					vSpace:     ct.vSpace,
 					hSpace:     ct.hSpace,
 					indents:    indents,
+					vSpaceOrig: ct.vSpaceOrig,
+					hSpaceOrig: ct.hSpaceOrig,
 				}
-				ret = append(ret, nt)
+				ret = append(ret, reindentComment(e, nt))
 			}
 			cTok.trlComments = nil
 		}
 
 	}
+
+	if e.WrapComments() {
+		ret = wrapComments(e, ret)
+	}
+	if e.AlignComments() {
+		ret = alignComments(e, ret)
+	}
 	return ret
 }
 
@@ -126,7 +126,7 @@ func TestSQLFiles(t *testing.T) {
 
 			////////////////////////////////////////////////////////////////////////
 			// Tag the tokens and compare to expected
-			bagMap, mainTokens, _, _ := tagBags(e, cleaned)
+			bagMap, mainTokens, _ := tagBags(e, cleaned)
 
 			err = writeTagged(taggedDir, d, file.Name(), mainTokens, bagMap, e, "Tagged")
 			if err != nil {
@@ -160,6 +160,7 @@ func TestSQLFiles(t *testing.T) {
 
 			////////////////////////////////////////////////////////////////////////
 			// Untag the tokens and compare to expected
+			fmtTokens = terminateStatements(e, fmtTokens, bagMap)
 			untagged := untagBags(fmtTokens, bagMap)
 			unstashed := unstashComments(e, untagged)
 
@@ -195,6 +196,204 @@ func TestSQLFiles(t *testing.T) {
 	}
 }
 
+func TestBodyFormatter(t *testing.T) {
+
+	input := `CREATE FUNCTION py_max (a integer, b integer) RETURNS integer AS $$
+    if a > b:
+      return a
+    return b
+$$ LANGUAGE plpython3u ;
+`
+
+	e := env.NewEnv()
+	e.SetDialect("postgres")
+
+	// The body is supplied without the common indentation...
+	RegisterBodyFormatter("plpython3u", BodyFormatterFunc(func(lang, body string) (string, error) {
+		if lang != "plpython3u" || !strings.HasPrefix(body, "if a > b:") {
+			return "", fmt.Errorf("unexpected body %q", body)
+		}
+		return strings.ReplaceAll(body, "\n  return a", "\n    return a"), nil
+	}))
+	defer RegisterBodyFormatter("plpython3u", nil)
+
+	// ...and the formatted body is re-indented
+	formatted, _, errs := FormatInput(e, input)
+	if len(errs) > 0 {
+		t.Fatalf("Error formatting input: %v", errs)
+	}
+	if !strings.Contains(formatted, "$$\n    if a > b:\n        return a\n    return b\n$$") {
+		t.Errorf("Body was not formatted:\n%s", formatted)
+	}
+
+	// DO blocks may have the LANGUAGE clause before the body
+	doInput := `DO LANGUAGE plpython3u $$
+    if a > b:
+      return a
+    return b
+$$ ;
+`
+
+	formatted, _, errs = FormatInput(e, doInput)
+	if len(errs) > 0 {
+		t.Fatalf("Error formatting input: %v", errs)
+	}
+	if !strings.Contains(formatted, "$$\n    if a > b:\n        return a\n    return b\n$$") {
+		t.Errorf("DO body was not formatted:\n%s", formatted)
+	}
+
+	// Failures leave the body as is
+	RegisterBodyFormatter("plpython3u", BodyFormatterFunc(func(lang, body string) (string, error) {
+		return "", fmt.Errorf("nope")
+	}))
+
+	formatted, warns, errs := FormatInput(e, input)
+	if len(errs) > 0 {
+		t.Fatalf("Error formatting input: %v", errs)
+	}
+	if !strings.Contains(formatted, "$$\n    if a > b:\n      return a\n    return b\n$$") {
+		t.Errorf("Body was modified:\n%s", formatted)
+	}
+	if len(warns) != 1 || !strings.Contains(warns[0], "nope") {
+		t.Errorf("Expected the failure to be reported as a warning, got %v", warns)
+	}
+
+	// Single line bodies stay on a single line
+	RegisterBodyFormatter("plpython3u", BodyFormatterFunc(func(lang, body string) (string, error) {
+		if body != "return 1\n" {
+			return "", fmt.Errorf("unexpected body %q", body)
+		}
+		return body, nil
+	}))
+
+	oneLineInput := `CREATE FUNCTION py_one () RETURNS integer AS $$ return 1 $$ LANGUAGE plpython3u ;
+`
+
+	formatted, warns, errs = FormatInput(e, oneLineInput)
+	if len(errs) > 0 {
+		t.Fatalf("Error formatting input: %v", errs)
+	}
+	if len(warns) > 0 {
+		t.Errorf("Unexpected warnings: %v", warns)
+	}
+	if !strings.Contains(formatted, "$$ return 1 $$") {
+		t.Errorf("Single line body was not kept on a single line:\n%s", formatted)
+	}
+}
+
+func TestCommandFormatter(t *testing.T) {
+
+	body := "if a > b:\n  return a\nreturn b\n"
+
+	var tests = []struct {
+		name    string
+		command string
+		body    string
+		want    string
+		wantErr bool
+	}{
+		{"formats the body", "sed -e s/return/yield/", body, "if a > b:\n  yield a\nyield b\n", false},
+		{"no command", "  ", body, "", true},
+		{"failing command", "false", body, "", true},
+		{"missing command", "sqlfmt-no-such-command", body, "", true},
+		{"no output", "true", body, "", true},
+		{"no output for an empty body", "true", "", "", false},
+	}
+
+	for _, tc := range tests {
+		got, err := CommandFormatter{Command: tc.command}.FormatBody("plpython3u", tc.body)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%s: unexpected error result %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+
+	input := `CREATE FUNCTION py_max (a integer, b integer) RETURNS integer AS $$
+    if a > b:
+      return a
+    return b
+$$ LANGUAGE plpython3u ;
+`
+
+	// Commands configured via the environment are used for the bodies...
+	e := env.NewEnv()
+	e.SetDialect("postgres")
+	e.SetBodyFormatterCommand("plpython3u", "sed -e s/return/yield/")
+
+	formatted, warns, errs := FormatInput(e, input)
+	if len(errs) > 0 {
+		t.Fatalf("Error formatting input: %v", errs)
+	}
+	if len(warns) > 0 {
+		t.Errorf("Unexpected warnings: %v", warns)
+	}
+	if !strings.Contains(formatted, "$$\n    if a > b:\n      yield a\n    yield b\n$$") {
+		t.Errorf("Body was not formatted:\n%s", formatted)
+	}
+
+	// ...and commands that fail or return nothing leave the body as is and
+	// are reported as warnings
+	for _, command := range []string{"false", "true"} {
+		e.SetBodyFormatterCommand("plpython3u", command)
+
+		formatted, warns, errs = FormatInput(e, input)
+		if len(errs) > 0 {
+			t.Fatalf("Error formatting input: %v", errs)
+		}
+		if !strings.Contains(formatted, "$$\n    if a > b:\n      return a\n    return b\n$$") {
+			t.Errorf("%s: body was modified:\n%s", command, formatted)
+		}
+		if len(warns) != 1 {
+			t.Errorf("%s: expected the failure to be reported as a warning, got %v", command, warns)
+		}
+	}
+}
+
+func TestFormatEmbeddedDML(t *testing.T) {
+
+	e := env.NewEnv()
+	e.SetDialect("postgres")
+
+	// The format() specifiers survive formatting
+	input := "select %I, count(*) from %I.%I where id = %L and name like '%%x' and n = %2$s group by %1$I"
+
+	z, ok := formatEmbeddedDML(e, input, 8)
+	if !ok {
+		t.Fatalf("DML was not formatted: %q", input)
+	}
+	formatted := strings.Join(z, "\n")
+	for _, spec := range []string{"%I,", "%I.%I", "%L", "'%%x'", "%2$s", "%1$I"} {
+		if !strings.Contains(formatted, spec) {
+			t.Errorf("Specifier %q was not preserved:\n%s", spec, formatted)
+		}
+	}
+	if strings.Contains(formatted, "sqlfmt_ph_") {
+		t.Errorf("Placeholder was not replaced:\n%s", formatted)
+	}
+	if !strings.HasPrefix(formatted, "SELECT") {
+		t.Errorf("DML was not formatted:\n%s", formatted)
+	}
+
+	// DML that is indented too far to fit the max line length is left as is
+	if z, ok := formatEmbeddedDML(e, input, e.MaxLineLength()-60); ok {
+		t.Errorf("DML was formatted with too narrow a width:\n%s", strings.Join(z, "\n"))
+	}
+
+	// Anything that isn't DML is left as is
+	for _, input := range []string{
+		"create table %I ( id integer )",
+		"drop table if exists %I.%I",
+		"",
+	} {
+		if z, ok := formatEmbeddedDML(e, input, 8); ok {
+			t.Errorf("Non-DML %q was formatted:\n%s", input, strings.Join(z, "\n"))
+		}
+	}
+}
+
 func compareFiles(dir, d, fName string) error {
 
 	actFile := path.Join(dir, "actual", d, fName)
@@ -0,0 +1,585 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/gsiems/sqlfmt/env"
+)
+
+/*
+CREATE [OR ALTER] PROCEDURE|FUNCTION|TRIGGER <name>
+    [@parameter <datatype> [, ...]]
+[RETURNS ...]
+[WITH ...]
+AS
+BEGIN
+    DECLARE @variable <datatype> ;
+    SET @variable = ... ;
+    IF <condition>
+    BEGIN
+        <statement>
+    END
+    ELSE
+        <statement>
+    BEGIN TRY
+        <statement>
+    END TRY
+    BEGIN CATCH
+        <statement>
+    END CATCH
+END
+GO
+*/
+
+// tsqlStmt tracks the state of a T-SQL statement for determining where the
+// statement ends. As T-SQL statements do not need to be terminated, the end of
+// a statement is indicated by the start of the next statement.
+type tsqlStmt struct {
+	caseDepth     int  // the CASE expression depth
+	parensDepth   int  // the parens depth
+	isStarted     bool // the first token of the statement has been seen
+	pendingCTE    bool // a WITH statement that has not yet seen its main DML
+	pendingInsert bool // an INSERT statement that has not yet seen its source
+	pendingSet    bool // an UPDATE statement that has not yet seen its SET
+}
+
+// isBoundary returns true if the token (at parens depth zero) starts a new
+// T-SQL statement
+func (s *tsqlStmt) isBoundary(pTok FmtToken, ctVal string) bool {
+	switch ctVal {
+	case "FETCH":
+		// as opposed to "OFFSET n ROWS FETCH ..."
+		switch pTok.AsUpper() {
+		case "ROW", "ROWS":
+			return false
+		}
+		return true
+	case "EXEC", "EXECUTE":
+		// as opposed to "INSERT INTO ... EXEC ..."
+		return !s.pendingInsert
+	case "BEGIN", "BREAK", "CLOSE", "COMMIT", "CONTINUE", "DEALLOCATE",
+		"DECLARE", "GOTO", "IF", "OPEN", "PRINT", "RAISERROR", "RETURN",
+		"ROLLBACK", "SAVE", "THROW", "WAITFOR", "WHILE":
+		return true
+	case "ALTER", "CREATE", "DENY", "DROP", "GRANT", "REVOKE", "TRUNCATE":
+		return true
+	case "ELSE", "END":
+		return s.caseDepth == 0
+	case "SET":
+		return !s.pendingSet
+	case "SELECT":
+		switch pTok.AsUpper() {
+		case "ALL", "EXCEPT", "FOR", "INTERSECT", "UNION":
+			// compound queries and cursor declarations
+			return false
+		}
+		return !s.pendingCTE && !s.pendingInsert
+	case "DELETE", "INSERT", "MERGE", "MERGE INTO", "UPDATE":
+		switch pTok.AsUpper() {
+		case "THEN":
+			// the actions of a MERGE statement
+			return false
+		case "AFTER", "FOR", "INSTEAD OF", "OF", "ON", ",":
+			// trigger events, referential actions, and FOR UPDATE
+			return false
+		}
+		return !s.pendingCTE
+	}
+	return false
+}
+
+// track updates the statement state for the token
+func (s *tsqlStmt) track(ctVal, pKwVal string) {
+
+	isFirst := !s.isStarted
+	s.isStarted = true
+
+	switch ctVal {
+	case "(":
+		s.parensDepth++
+	case ")":
+		s.parensDepth--
+	case "CASE":
+		s.caseDepth++
+	case "END":
+		if s.caseDepth > 0 {
+			s.caseDepth--
+		}
+	}
+
+	if s.parensDepth > 0 {
+		return
+	}
+
+	switch ctVal {
+	case "WITH":
+		// as opposed to table hints, "WITH (NOLOCK)"
+		s.pendingCTE = s.pendingCTE || isFirst
+	case "SELECT", "DELETE", "MERGE", "MERGE INTO":
+		s.pendingCTE = false
+		s.pendingInsert = false
+	case "INSERT":
+		s.pendingCTE = false
+		s.pendingInsert = true
+	case "VALUES", "EXEC", "EXECUTE":
+		s.pendingInsert = false
+	case "UPDATE":
+		// as opposed to "FOR UPDATE" in cursor declarations
+		s.pendingCTE = false
+		s.pendingSet = pKwVal != "FOR"
+	case "SET":
+		s.pendingSet = false
+	}
+}
+
+// isTSQLDDLBoundary returns true if the token (at parens depth zero) starts a
+// new T-SQL statement that follows an unterminated DDL statement. Unlike for
+// DML, SET (ALTER DATABASE ... SET ...), IF (DROP TABLE IF EXISTS ...), ALTER
+// (ALTER TABLE ... ALTER COLUMN ...), and DROP (ALTER TABLE ... DROP ...) may
+// be part of the DDL statement.
+func isTSQLDDLBoundary(s *tsqlStmt, pTok FmtToken, ctVal string) bool {
+	switch {
+	case ctVal == "SET", ctVal == "ALTER", ctVal == "DROP":
+		return false
+	case ctVal == "IF" && pTok.IsKeyword():
+		return false
+	}
+	return s.isBoundary(pTok, ctVal)
+}
+
+// canOpenTSQLBag determines if a DML bag can be opened for a T-SQL statement
+// that does not follow a terminator
+func canOpenTSQLBag(pTok FmtToken, pKwVal, ctVal string) bool {
+
+	switch pKwVal {
+	case "GRANT", "DENY", "REVOKE":
+		return false
+	}
+
+	switch ctVal {
+	case "SELECT":
+		return true
+	case "DELETE", "INSERT", "MERGE", "TRUNCATE", "UPDATE":
+		// not trigger events or referential actions
+		switch pKwVal {
+		case "AFTER", "FOR", "INSTEAD OF", "OF", "ON":
+			return false
+		}
+		return pTok.value != ","
+	}
+	return false
+}
+
+// isTSQLBlockEnd returns true if the token closes a BEGIN ... END block
+func isTSQLBlockEnd(ctVal, ntVal string) bool {
+	return ctVal == "END" && ntVal != "TRAN" && ntVal != "TRANSACTION"
+}
+
+// isTSQLBlockStart returns true if the token opens a BEGIN ... END block (as
+// opposed to starting a transaction)
+func isTSQLBlockStart(ctVal, ntVal string) bool {
+	switch ntVal {
+	case "TRAN", "TRANSACTION", "DISTRIBUTED", "DIALOG", "CONVERSATION":
+		return false
+	}
+	return ctVal == "BEGIN"
+}
+
+// tagMSSQLPL ensures that the DDL for creating MS SQL-Server functions,
+// procedures, and triggers, as well as any T-SQL batches that contain
+// control-of-flow statements, are properly tagged
+func tagMSSQLPL(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
+
+	// As the CREATE statement for a function, procedure, or trigger needs to
+	// be the only statement in the batch, the end of the batch (the next GO
+	// or the end of the input) is the end of the PL object. Likewise for
+	// batches of control-of-flow statements.
+
+	var remainder []FmtToken
+	var bagTokens []FmtToken
+	isInBag := false
+	bagId := 0
+	pKwVal := ""
+	var pTok FmtToken
+
+	for idx, cTok := range m {
+
+		ctVal := cTok.AsUpper()
+
+		if isInBag && cTok.IsData() {
+			key := bagKey(PLxBody, bagId)
+			bagMap[key] = TokenBag{
+				id:     bagId,
+				typeOf: PLxBody,
+				tokens: bagTokens,
+			}
+
+			isInBag = false
+			bagTokens = nil
+		}
+
+		switch isInBag {
+		case true:
+			bagTokens = append(bagTokens, cTok)
+
+		case false:
+
+			// check for the beginning of the PL object
+			openBag := false
+			switch ctVal {
+			case "FUNCTION", "PROC", "PROCEDURE", "TRIGGER":
+				switch pKwVal {
+				case "CREATE", "ALTER":
+					openBag = true
+				}
+			case "BEGIN", "DECLARE", "IF", "PRINT", "RAISERROR", "SET",
+				"THROW", "WHILE":
+				switch {
+				case idx == 0, pTok.IsTerminator(), pTok.IsBag(), pTok.IsData():
+					openBag = true
+				}
+			}
+
+			switch openBag {
+			case true:
+				isInBag = true
+				bagId = cTok.id
+				bagTokens = append(bagTokens, cTok)
+
+				// Add a token that has the pointer to the new bag
+				remainder = append(remainder, FmtToken{
+					id:          bagId,
+					categoryOf:  PLxBody,
+					typeOf:      PLxBody,
+					vSpace:      cTok.vSpace,
+					indents:     cTok.indents,
+					hSpace:      cTok.hSpace,
+					vSpaceOrig:  cTok.vSpaceOrig,
+					hSpaceOrig:  cTok.hSpaceOrig,
+					ledComments: cTok.ledComments,
+					trlComments: cTok.trlComments,
+				})
+
+			default:
+				// Not in any PL object
+				remainder = append(remainder, cTok)
+			}
+		}
+
+		if cTok.IsKeyword() {
+			pKwVal = ctVal
+		}
+		pTok = cTok
+	}
+
+	if len(bagTokens) > 0 {
+		key := bagKey(PLxBody, bagId)
+
+		bagMap[key] = TokenBag{
+			id:     bagId,
+			typeOf: PLxBody,
+			tokens: bagTokens,
+		}
+	}
+
+	return remainder
+}
+
+func formatMSSQLPLKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
+
+	switch e.KeywordCase() {
+	case env.UpperCase:
+		// nada
+	default:
+		return tokens
+	}
+
+	idxMax := len(tokens) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+		switch tokens[idx].AsUpper() {
+		case "AFTER", "AND", "AS", "BEGIN", "BETWEEN", "BREAK", "CALLER",
+			"CASE", "CATCH", "CLOSE", "COMMIT", "CONTINUE", "CURSOR",
+			"DEALLOCATE", "DECLARE", "DEFAULT", "DELAY", "DELETE", "ELSE", "END",
+			"EXEC", "EXECUTE", "EXISTS", "FAST_FORWARD", "FETCH", "FOR", "FROM",
+			"FUNCTION", "GOTO", "IF", "IN", "INSERT", "INSTEAD OF", "INTO", "IS",
+			"LIKE", "LOCAL", "NEXT", "NOCOUNT", "NOT", "NULL", "OFF", "ON",
+			"OPEN", "OR", "OUT", "OUTPUT", "OWNER", "PRINT", "PROC", "PROCEDURE",
+			"RAISERROR", "READ_ONLY", "RECOMPILE", "RETURN", "RETURNS",
+			"ROLLBACK", "SAVE", "SCHEMABINDING", "SET", "STATIC", "TABLE",
+			"THEN", "THROW", "TRAN", "TRANSACTION", "TRIGGER", "TRY", "UPDATE",
+			"WAITFOR", "WHEN", "WHILE", "WITH", "XACT_ABORT":
+
+			tokens[idx].SetUpper()
+		}
+	}
+
+	return tokens
+}
+
+func formatMSSQLPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIndents int, forceInitVSpace bool) {
+
+	key := bagKey(bagType, bagId)
+
+	b, ok := bagMap[key]
+	if !ok {
+		return
+	}
+
+	if len(b.tokens) == 0 {
+		return
+	}
+
+	objType := b.tokens[0].AsUpper()
+
+	tokens := formatMSSQLPLKeywords(e, b.tokens)
+	idxMax := len(tokens) - 1
+
+	// The block stack is used for determining indentation. "BEGIN" is for
+	// BEGIN ... END blocks, "AS" is for the header of a PL object that hasn't
+	// yet seen its body, "BODY" is for the body of a PL object that isn't
+	// wrapped in a BEGIN ... END block, "IF" is for an IF, ELSE, or WHILE
+	// that hasn't yet seen the statement (or block) that it controls, and
+	// "STMT" is for a controlled statement that isn't wrapped in a
+	// BEGIN ... END block.
+	var bbStack plStack
+	var tFormatted []FmtToken
+	parensDepth := 0
+	caseExpr := 0 // the CASE expression depth
+	isHeader := false
+	isSig := false   // in the parameter list of a function or procedure
+	sigDone := false // the parameter list has been seen
+
+	switch objType {
+	case "FUNCTION", "PROC", "PROCEDURE", "TRIGGER":
+		isHeader = true
+	}
+
+	for idx := 0; idx <= idxMax; idx++ {
+
+		cTok := tokens[idx]
+		ctVal := cTok.AsUpper()
+
+		var pTok FmtToken
+		var nTok FmtToken
+		if idx > 0 {
+			pTok = tokens[idx-1]
+		}
+		if idx < idxMax {
+			nTok = tokens[idx+1]
+		}
+		ptVal := pTok.AsUpper()
+		ntVal := nTok.AsUpper()
+
+		////////////////////////////////////////////////////////////////
+		// Determine the preceding vertical spacing (if any) and the
+		// indentation level
+		honorVSpace := idx == 0
+		ensureVSpace := false
+		indents := baseIndents
+
+		switch isHeader {
+		case true:
+			switch {
+			case isSig && parensDepth == 1:
+				// the parameters for functions and procedures
+				switch ptVal {
+				case "(", ",":
+					ensureVSpace = ctVal != ")"
+				}
+				indents++
+			case parensDepth > 0:
+				// nada
+			case !sigDone && strings.HasPrefix(cTok.value, "@") && objType != "FUNCTION":
+				// procedure parameters that aren't wrapped in parens
+				switch {
+				case ptVal == ",", pTok.IsIdentifier():
+					ensureVSpace = true
+					isSig = true
+				}
+				indents++
+			case ctVal == "AS" && ptVal != "EXECUTE":
+				ensureVSpace = true
+				isHeader = false
+				isSig = false
+				bbStack.Push(ctVal)
+			default:
+				switch ctVal {
+				case "RETURNS", "WITH":
+					ensureVSpace = true
+					isSig = false
+					sigDone = true
+				case "ON", "AFTER", "FOR", "INSTEAD OF":
+					ensureVSpace = objType == "TRIGGER"
+					indents++
+				}
+			}
+
+		case false:
+
+			isStmtStart := false
+			switch {
+			case parensDepth > 0, caseExpr > 0:
+				// nada
+			case idx == 0:
+				isStmtStart = true
+			case cTok.IsBag():
+				// the query for a cursor declaration is part of the
+				// declaration
+				isStmtStart = ptVal != "FOR"
+			case pTok.IsTerminator(), pTok.IsLabel(), cTok.IsLabel():
+				isStmtStart = true
+			default:
+				var s tsqlStmt
+				isStmtStart = s.isBoundary(pTok, ctVal)
+			}
+
+			isBlockStart := isStmtStart && isTSQLBlockStart(ctVal, ntVal)
+			isBlockEnd := isStmtStart && isTSQLBlockEnd(ctVal, ntVal)
+			isElseIf := ctVal == "IF" && ptVal == "ELSE"
+
+			if isStmtStart && idx > 0 && !isElseIf {
+				switch bbStack.Last() {
+				case "AS":
+					// the first statement (or block) of a PL body
+					_ = bbStack.Pop()
+					if !isBlockStart {
+						bbStack.Push("BODY")
+					}
+				case "IF":
+					// the first statement (or block) that is controlled by
+					// an IF, ELSE, or WHILE
+					_ = bbStack.Pop()
+					if !isBlockStart {
+						bbStack.Push("STMT")
+					}
+				default:
+					if ctVal == "ELSE" {
+						if bbStack.Last() == "STMT" {
+							_ = bbStack.Pop()
+						}
+					} else {
+						for bbStack.Last() == "STMT" {
+							_ = bbStack.Pop()
+						}
+					}
+				}
+
+				if isBlockEnd {
+					_ = bbStack.Pop()
+				}
+			}
+
+			indents = baseIndents + bbStack.Indents()
+
+			switch {
+			case isElseIf:
+				// nada
+			case isStmtStart:
+				ensureVSpace = !pTok.IsLabel()
+			case cTok.IsBag() && ptVal == "FOR":
+				// the query for a cursor declaration starts on a new line
+				ensureVSpace = true
+				indents++
+			case cTok.IsBag():
+				honorVSpace = true
+			}
+
+			////////////////////////////////////////////////////////////
+			// Update the block stack and CASE expression depth
+			switch {
+			case isBlockStart:
+				bbStack.Push("BEGIN")
+			case isStmtStart:
+				switch ctVal {
+				case "IF", "WHILE":
+					bbStack.Push("IF")
+				case "ELSE":
+					if ntVal != "IF" {
+						bbStack.Push("IF")
+					}
+				}
+			}
+
+			switch ctVal {
+			case "CASE":
+				caseExpr++
+			case "END":
+				if caseExpr > 0 {
+					caseExpr--
+				}
+			}
+		}
+
+		// For code comments
+		switch {
+		case pTok.HasTrailingComments():
+			ensureVSpace = true
+		case cTok.HasLeadingComments():
+			ensureVSpace = true
+		}
+
+		cTok.AdjustVSpace(ensureVSpace, honorVSpace)
+
+		////////////////////////////////////////////////////////////////
+		// Adjust the parens depth
+		switch cTok.value {
+		case "(":
+			parensDepth++
+			isSig = isHeader && !sigDone
+		case ")":
+			parensDepth--
+			if isSig && parensDepth == 0 {
+				isSig = false
+				sigDone = true
+			}
+		}
+
+		////////////////////////////////////////////////////////////////
+		// Update the type and amount of white-space before the token
+		if cTok.vSpace > 0 {
+			cTok.AdjustIndents(indents)
+		} else {
+			cTok.AdjustHSpace(e, pTok)
+		}
+
+		// set the line wrapping break points
+		switch {
+		case cTok.vSpace == 0:
+			// nada
+		case cTok.IsKeyword():
+			cTok.fbp = true
+		case pTok.IsKeyword():
+			cTok.fbp = true
+		}
+
+		tFormatted = append(tFormatted, cTok)
+	}
+
+	tFormatted = wrapLines(e, bagType, tFormatted)
+
+	parensDepth = 0
+	indents := 0
+	for _, cTok := range tFormatted {
+
+		switch cTok.value {
+		case "(":
+			parensDepth++
+		case ")":
+			parensDepth--
+		default:
+			if cTok.vSpace > 0 {
+				parensDepth = 0
+				indents = cTok.indents
+			}
+			if cTok.IsBag() {
+				formatBag(e, bagMap, cTok.typeOf, cTok.id, indents+parensDepth, true)
+			}
+		}
+	}
+
+	adjustCommentIndents(bagType, &tFormatted)
+
+	// Replace the mapped tokens with the newly formatted tokens
+	UpsertMappedBag(bagMap, b.typeOf, b.id, "", tFormatted)
+}
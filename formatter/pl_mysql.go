@@ -0,0 +1,481 @@
+package formatter
+
+import (
+	"github.com/gsiems/db-dialect/dialect"
+	"github.com/gsiems/sqlfmt/env"
+)
+
+/*
+DELIMITER $$
+
+CREATE [DEFINER = user] PROCEDURE|FUNCTION|TRIGGER|EVENT <name> ...
+BEGIN
+    [DECLARE ... ;]
+    [DECLARE ... HANDLER FOR ... <statement> ;]
+    <statement> ;
+    [label:] LOOP|WHILE ... DO|REPEAT
+        <statement> ;
+    END LOOP|WHILE|REPEAT [label] ;
+END $$
+
+DELIMITER ;
+*/
+
+// isMySQLish returns true if the dialect is either MySQL or MariaDB
+func isMySQLish(e *env.Env) bool {
+	switch e.Dialect() {
+	case dialect.MySQL, dialect.MariaDB:
+		return true
+	}
+	return false
+}
+
+// isMySQLStmtStart returns true if the token following the previous token
+// could be the first token of a statement in a MySQL/MariaDB stored program
+func isMySQLStmtStart(pTok FmtToken) bool {
+	switch pTok.AsUpper() {
+	case "BEGIN", "THEN", "ELSE", "DO", "LOOP", "REPEAT":
+		return true
+	}
+	return pTok.IsTerminator() || pTok.IsLabel() || pTok.IsBag()
+}
+
+// isMySQLBlockEnd returns true if the token closes a compound statement
+func isMySQLBlockEnd(ctVal string) bool {
+	switch ctVal {
+	case "END", "END CASE", "END IF", "END LOOP", "END REPEAT", "END WHILE":
+		return true
+	}
+	return false
+}
+
+// tagMySQLPL ensures that the DDL for creating MySQL/MariaDB stored programs
+// (functions, procedures, triggers, and events) are properly tagged
+func tagMySQLPL(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
+
+	// One issue with tagging MySQL stored programs is that, while they are
+	// usually wrapped in a DELIMITER block, they don't need to be. Therefore
+	// the end of the program is the first terminator that isn't inside of a
+	// compound statement.
+
+	var remainder []FmtToken
+	var bagTokens []FmtToken
+	isInBag := false
+	bagId := 0
+	depth := 0    // the compound statement depth
+	caseExpr := 0 // the CASE expression (as opposed to CASE statement) depth
+	pKwVal := ""
+	var pTok FmtToken
+
+	for _, cTok := range m {
+
+		ctVal := cTok.AsUpper()
+
+		switch isInBag {
+		case true:
+			bagTokens = append(bagTokens, cTok)
+			closeBag := false
+
+			switch {
+			case cTok.IsBag():
+				// single statement trigger or event body
+				closeBag = depth <= 0
+			case cTok.IsTerminator():
+				closeBag = depth <= 0
+			case ctVal == "BEGIN":
+				depth++
+			case ctVal == "CASE":
+				if isMySQLStmtStart(pTok) && caseExpr == 0 {
+					depth++
+				} else {
+					caseExpr++
+				}
+			case ctVal == "IF", ctVal == "LOOP", ctVal == "REPEAT", ctVal == "WHILE":
+				if isMySQLStmtStart(pTok) && caseExpr == 0 {
+					depth++
+				}
+			case ctVal == "END" && caseExpr > 0:
+				caseExpr--
+			case isMySQLBlockEnd(ctVal):
+				depth--
+			}
+
+			if closeBag {
+				key := bagKey(PLxBody, bagId)
+				bagMap[key] = TokenBag{
+					id:     bagId,
+					typeOf: PLxBody,
+					tokens: bagTokens,
+				}
+
+				isInBag = false
+				bagTokens = nil
+			}
+
+		case false:
+
+			// check for the beginning of the PL object
+			openBag := false
+			switch ctVal {
+			case "FUNCTION", "PROCEDURE", "TRIGGER", "EVENT":
+				switch pKwVal {
+				case "DROP", "ALTER", "SHOW":
+					// nada
+				default:
+					openBag = true
+				}
+			}
+
+			switch openBag {
+			case true:
+				isInBag = true
+				bagId = cTok.id
+				depth = 0
+				caseExpr = 0
+				bagTokens = append(bagTokens, cTok)
+
+				// Add a token that has the pointer to the new bag
+				remainder = append(remainder, FmtToken{
+					id:          bagId,
+					categoryOf:  PLxBody,
+					typeOf:      PLxBody,
+					vSpace:      cTok.vSpace,
+					indents:     cTok.indents,
+					hSpace:      cTok.hSpace,
+					vSpaceOrig:  cTok.vSpaceOrig,
+					hSpaceOrig:  cTok.hSpaceOrig,
+					ledComments: cTok.ledComments,
+					trlComments: cTok.trlComments,
+				})
+
+			default:
+				// Not in any PL object
+				remainder = append(remainder, cTok)
+			}
+		}
+
+		if cTok.IsKeyword() {
+			pKwVal = ctVal
+		}
+		pTok = cTok
+	}
+
+	// On the off chance that the bag wasn't closed properly (incomplete or
+	// incorrect statement submitted?), ensure that no tokens are lost.
+	if len(bagTokens) > 0 {
+		key := bagKey(PLxBody, bagId)
+
+		bagMap[key] = TokenBag{
+			id:     bagId,
+			typeOf: PLxBody,
+			tokens: bagTokens,
+		}
+	}
+
+	return remainder
+}
+
+func formatMySQLPLKeywords(e *env.Env, objType string, tokens []FmtToken) []FmtToken {
+
+	switch e.KeywordCase() {
+	case env.UpperCase:
+		// nada
+	default:
+		return tokens
+	}
+
+	idxMax := len(tokens) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+		switch tokens[idx].AsUpper() {
+		case "AFTER", "AND", "BEFORE", "BEGIN", "BETWEEN", "CALL", "CASE",
+			"CLOSE", "COMMENT", "COMMIT", "CONTAINS", "CONTINUE", "CURSOR",
+			"DATA", "DECLARE", "DEFAULT", "DEFINER", "DELETE", "DETERMINISTIC",
+			"DO", "EACH", "ELSE", "ELSEIF", "END", "END CASE", "END IF",
+			"END LOOP", "END REPEAT", "END WHILE", "EVENT", "EXISTS", "EXIT",
+			"FETCH", "FOLLOWS", "FOR", "FOUND", "FUNCTION", "HANDLER", "IF",
+			"IN", "INOUT", "INSERT", "INTO", "INVOKER", "IS", "ITERATE",
+			"LANGUAGE", "LEAVE", "LIKE", "LOOP", "MESSAGE_TEXT", "MODIFIES", "NO",
+			"NOT", "NULL", "ON", "OPEN", "OR", "OUT", "PRECEDES", "PROCEDURE",
+			"READS", "REPEAT", "RESIGNAL", "RETURN", "RETURNS", "ROLLBACK",
+			"ROW", "SCHEDULE", "SECURITY", "SET", "SIGNAL", "SQL",
+			"SQLEXCEPTION", "SQLSTATE", "SQLWARNING", "THEN", "TRIGGER", "UNDO",
+			"UNTIL", "UPDATE", "WHEN", "WHILE":
+
+			tokens[idx].SetUpper()
+		}
+
+		if objType == "EVENT" {
+			switch tokens[idx].AsUpper() {
+			case "AT", "COMPLETION", "DAY", "DISABLE", "ENABLE", "ENDS",
+				"EVERY", "HOUR", "MINUTE", "MONTH", "PRESERVE", "QUARTER",
+				"SECOND", "STARTS", "WEEK", "YEAR":
+
+				tokens[idx].SetUpper()
+			}
+		}
+	}
+
+	return tokens
+}
+
+func formatMySQLPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIndents int, forceInitVSpace bool) {
+
+	key := bagKey(bagType, bagId)
+
+	b, ok := bagMap[key]
+	if !ok {
+		return
+	}
+
+	if len(b.tokens) == 0 {
+		return
+	}
+
+	objType := b.tokens[0].AsUpper()
+
+	tokens := formatMySQLPLKeywords(e, objType, b.tokens)
+	idxMax := len(tokens) - 1
+
+	var bbStack plStack
+	var tFormatted []FmtToken
+	parensDepth := 0
+	caseExpr := 0    // the CASE expression (as opposed to CASE statement) depth
+	isHeader := true // in the header (prior to the body) of the PL object
+	isSig := false   // in the parameter list of a function or procedure
+	sigDone := false // the parameter list has been seen
+	stmtKwVal := ""  // the first keyword of the current statement
+	pKwVal := ""
+
+	for idx := 0; idx <= idxMax; idx++ {
+
+		cTok := tokens[idx]
+		ctVal := cTok.AsUpper()
+
+		var pTok FmtToken
+		var nTok FmtToken
+		if idx > 0 {
+			pTok = tokens[idx-1]
+		}
+		if idx < idxMax {
+			nTok = tokens[idx+1]
+		}
+		ptVal := pTok.AsUpper()
+		ntVal := nTok.AsUpper()
+
+		isStmtStart := idx > 0 && !isHeader && parensDepth == 0 && caseExpr == 0 && isMySQLStmtStart(pTok)
+		if isStmtStart {
+			stmtKwVal = ctVal
+		}
+
+		////////////////////////////////////////////////////////////////
+		// Determine the preceding vertical spacing (if any) and the
+		// indentation level
+		honorVSpace := idx == 0
+		ensureVSpace := false
+		indents := baseIndents
+
+		switch isHeader {
+		case true:
+			switch {
+			case isSig:
+				// the parameters for functions and procedures
+				if parensDepth == 1 {
+					switch ptVal {
+					case "(", ",":
+						ensureVSpace = ctVal != ")"
+					}
+				}
+				indents++
+			case parensDepth > 0:
+				// nada
+			case cTok.IsBag():
+				// single statement trigger or event body
+				ensureVSpace = true
+				isHeader = false
+			case ptVal == "ROW" && ctVal != "FOLLOWS" && ctVal != "PRECEDES":
+				// trigger body
+				ensureVSpace = true
+				isHeader = false
+			default:
+				switch ctVal {
+				case "BEGIN", "RETURN":
+					ensureVSpace = true
+					isHeader = false
+				case "COMMENT", "CONTAINS", "LANGUAGE", "MODIFIES", "NO",
+					"READS", "RETURNS":
+					ensureVSpace = true
+				case "NOT":
+					ensureVSpace = ntVal == "DETERMINISTIC"
+				case "DETERMINISTIC":
+					ensureVSpace = ptVal != "NOT"
+				case "SQL":
+					switch ptVal {
+					case "CONTAINS", "NO", "READS", "MODIFIES":
+						// nada
+					default:
+						ensureVSpace = true
+					}
+				case "BEFORE", "AFTER", "FOR", "FOLLOWS", "PRECEDES":
+					ensureVSpace = objType == "TRIGGER"
+					indents++
+				case "ON", "ENABLE", "DISABLE":
+					ensureVSpace = objType == "EVENT" && pKwVal != "ON"
+					indents++
+				case "DO":
+					ensureVSpace = objType == "EVENT"
+					isHeader = false
+				}
+			}
+
+			if ctVal == "BEGIN" {
+				bbStack.Push(ctVal)
+			}
+
+		case false:
+
+			isBlockEnd := isMySQLBlockEnd(ctVal) && !(ctVal == "END" && caseExpr > 0)
+			if isBlockEnd {
+				_ = bbStack.Pop()
+			}
+
+			indents = baseIndents + bbStack.Indents()
+
+			switch {
+			case isStmtStart:
+				// labels are placed on the same line as the statement that
+				// they label
+				ensureVSpace = !pTok.IsLabel()
+			case isBlockEnd:
+				ensureVSpace = true
+			}
+
+			switch ctVal {
+			case "BEGIN":
+				ensureVSpace = true
+			case "ELSE", "ELSEIF", "UNTIL":
+				if caseExpr == 0 {
+					ensureVSpace = true
+					indents--
+				}
+			case "WHEN":
+				if caseExpr == 0 && bbStack.Last() == "CASE" {
+					ensureVSpace = true
+					indents--
+				}
+			case "SET":
+				switch stmtKwVal {
+				case "SIGNAL", "RESIGNAL":
+					ensureVSpace = true
+					indents++
+				}
+			}
+
+			// The query for a cursor declaration starts on a new line
+			if cTok.IsBag() && stmtKwVal == "DECLARE" && ptVal == "FOR" {
+				ensureVSpace = true
+				indents++
+			}
+
+			////////////////////////////////////////////////////////////
+			// Update the block stack and CASE expression depth
+			switch ctVal {
+			case "BEGIN":
+				bbStack.Push(ctVal)
+			case "IF", "LOOP", "REPEAT", "WHILE":
+				if isStmtStart {
+					bbStack.Push(ctVal)
+				}
+			case "CASE":
+				if isStmtStart {
+					bbStack.Push(ctVal)
+				} else {
+					caseExpr++
+				}
+			case "END":
+				if caseExpr > 0 {
+					caseExpr--
+				}
+			}
+
+			if !ensureVSpace && cTok.IsBag() {
+				honorVSpace = true
+			}
+		}
+
+		// For code comments
+		switch {
+		case pTok.HasTrailingComments():
+			ensureVSpace = true
+		case cTok.HasLeadingComments():
+			ensureVSpace = true
+		}
+
+		cTok.AdjustVSpace(ensureVSpace, honorVSpace)
+
+		////////////////////////////////////////////////////////////////
+		// Adjust the parens depth
+		switch cTok.value {
+		case "(":
+			parensDepth++
+			isSig = isHeader && !sigDone
+		case ")":
+			parensDepth--
+			if isSig && parensDepth == 0 {
+				isSig = false
+				sigDone = true
+			}
+		}
+
+		////////////////////////////////////////////////////////////////
+		// Update the type and amount of white-space before the token
+		if cTok.vSpace > 0 {
+			cTok.AdjustIndents(indents)
+		} else {
+			cTok.AdjustHSpace(e, pTok)
+		}
+
+		// set the line wrapping break points
+		switch {
+		case cTok.vSpace == 0:
+			// nada
+		case cTok.IsKeyword():
+			cTok.fbp = true
+		case pTok.IsKeyword():
+			cTok.fbp = true
+		}
+
+		if cTok.IsKeyword() {
+			pKwVal = ctVal
+		}
+
+		tFormatted = append(tFormatted, cTok)
+	}
+
+	tFormatted = wrapLines(e, bagType, tFormatted)
+
+	parensDepth = 0
+	indents := 0
+	for _, cTok := range tFormatted {
+
+		switch cTok.value {
+		case "(":
+			parensDepth++
+		case ")":
+			parensDepth--
+		default:
+			if cTok.vSpace > 0 {
+				parensDepth = 0
+				indents = cTok.indents
+			}
+			if cTok.IsBag() {
+				formatBag(e, bagMap, cTok.typeOf, cTok.id, indents+parensDepth, true)
+			}
+		}
+	}
+
+	adjustCommentIndents(bagType, &tFormatted)
+
+	// Replace the mapped tokens with the newly formatted tokens
+	UpsertMappedBag(bagMap, b.typeOf, b.id, "", tFormatted)
+}
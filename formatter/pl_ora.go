@@ -59,6 +59,10 @@ func tagOraPL(m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 				case "BEGIN", "IF", "LOOP", "CASE":
 					if obj, ok := objs[plCnt]; ok {
 						obj.depth++
+						if ctVal == "BEGIN" {
+							// triggers don't have an IS/AS
+							obj.hasIs = true
+						}
 						objs[plCnt] = obj
 					}
 				case "END", "END CASE", "END IF", "END LOOP":
@@ -71,7 +75,7 @@ func tagOraPL(m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 						obj.hasLang = true
 						objs[plCnt] = obj
 					}
-				case "IS", "AS":
+				case "IS", "AS", "DECLARE":
 					if obj, ok := objs[plCnt]; ok {
 						obj.hasIs = true
 						objs[plCnt] = obj
@@ -195,7 +199,11 @@ func formatOraPLKeywords(e *env.Env, objType string, tokens []FmtToken) []FmtTok
 			"OPEN", "OR", "IMMEDIATE", "RAISE", "REFRESH", "RETURN", "THEN",
 			"VIEW", "WHEN", "WHILE", "FUNCTION", "PROCEDURE", "OUT", "PACKAGE",
 			"PACKAGE BODY", "PRAGMA", "RECORD", "TABLE", "TYPE BODY", "VALUES",
-			"TYPE", "COMMIT", "ROLLBACK", "USING":
+			"TYPE", "COMMIT", "ROLLBACK", "USING", "CURSOR", "AUTHID",
+			"DEFINER", "CURRENT_USER", "LIMIT", "INDICES", "SAVE", "EXCEPTIONS",
+			"OTHERS", "MEMBER", "STATIC", "CONSTRUCTOR", "MAP", "ORDER", "SELF",
+			"RESULT", "AUTONOMOUS_TRANSACTION", "EXCEPTION_INIT",
+			"SERIALLY_REUSABLE", "RESTRICT_REFERENCES", "INLINE":
 
 			tokens[idx].SetUpper()
 		}
@@ -242,18 +250,36 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 	sigStat := 0
 	var tFormatted []FmtToken
 	pKwVal := ""
+	isCursor := false // in a cursor declaration
+	isForall := false // in a FORALL statement
+	isHeader := true  // in the header (prior to the initial IS/AS) of the PL object
 
 	for idx := 0; idx <= idxMax; idx++ {
 
 		cTok := tokens[idx]
 		ctVal := cTok.AsUpper()
 
+		switch ctVal {
+		case "CURSOR":
+			isCursor = pKwVal != "OPEN"
+		case "FORALL":
+			isForall = true
+		}
+
 		////////////////////////////////////////////////////////////////
 		// Update (push) the block/branch stack
 		switch ctVal {
 		case "TRIGGER", "PACKAGE", "PACKAGE BODY", "TYPE BODY", "FUNCTION", "PROCEDURE":
 			bbStack.Push(ctVal)
-		case "DECLARE", "BEGIN", "EXCEPTION":
+		case "DECLARE":
+			if bbStack.Last() == "TRIGGER" {
+				// the trigger declaration section replaces the trigger
+				// header in the same way that BEGIN does
+				bbStack.Set(ctVal)
+			} else {
+				bbStack.Upsert(ctVal)
+			}
+		case "BEGIN", "EXCEPTION":
 			bbStack.Upsert(ctVal)
 		case "IF", "LOOP", "CASE":
 			// WHILE/FOR vs. LOOP???
@@ -325,6 +351,8 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 					}
 				case "SHARING", "AUTHID":
 					ensureVSpace = true
+				case "AS", "IS":
+					ensureVSpace = isHeader
 				case "DEFAULT": // COLLATION
 					ensureVSpace = true
 				case "ACCESSIBLE": // BY
@@ -354,6 +382,12 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 				case "IS", "AS":
 					ensureVSpace = true
 				}
+			case "MEMBER", "STATIC", "CONSTRUCTOR", "MAP", "ORDER":
+				// type body methods
+				switch ntVal {
+				case "FUNCTION", "PROCEDURE", "MEMBER":
+					ensureVSpace = parensDepth == 0
+				}
 			case "ACCESSIBLE", "AGGREGATE", "AUTHID", "DETERMINISTIC",
 				"EXTERNAL", "PARALLEL_ENABLE", "PIPELINED", "RESULT_CACHE",
 				"SHARING", "SQL_MACRO":
@@ -369,11 +403,14 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 			switch ctVal {
 			case "BEGIN", "BREAK", "CALL", "CLOSE", "CONTINUE", "DECLARE",
 				"ELSEIF", "ELSIF", "END CASE", "END IF", "END LOOP", "EXIT",
-				"FORALL", "FOREACH", "IF", "OPEN", "WHILE", "EXCEPTION",
-				"USING":
+				"FORALL", "FOREACH", "IF", "OPEN", "WHILE", "USING", "PRAGMA":
 
 				ensureVSpace = true
 
+			case "EXCEPTION":
+				// as opposed to declaring a user-defined exception
+				ensureVSpace = ntVal != ";"
+
 			case "INTO":
 				switch ptVal {
 				case "BULK COLLECT":
@@ -385,7 +422,7 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 				honorVSpace = true
 			case "AS":
 				switch ptVal {
-				case "NEW", "OLD":
+				case "NEW", "OLD", "SELF":
 					// nada
 				default:
 					switch ntVal {
@@ -397,8 +434,10 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 				}
 
 			case "IS":
-				switch ntVal {
-				case "NOT", "NULL":
+				switch {
+				case isCursor:
+					// nada
+				case ntVal == "NOT", ntVal == "NULL":
 					// nada
 				default:
 					switch pKwVal {
@@ -475,7 +514,7 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 			ensureVSpace = true
 		case "AS":
 			switch ctVal {
-			case "OLD", "NEW":
+			case "OLD", "NEW", "RESULT":
 				// nada
 			default:
 				if parensDepth == 0 {
@@ -535,6 +574,13 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 			}
 		}
 
+		// The query for a cursor declaration and the DML for a FORALL
+		// statement start on a new line
+		isCursorOrForall := cTok.IsBag() && (isCursor || isForall)
+		if isCursorOrForall {
+			ensureVSpace = true
+		}
+
 		cTok.AdjustVSpace(ensureVSpace, honorVSpace)
 
 		////////////////////////////////////////////////////////////////
@@ -598,6 +644,10 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 					indents++
 				}
 			}
+
+			if isCursorOrForall {
+				indents++
+			}
 		}
 
 		////////////////////////////////////////////////////////////////
@@ -646,6 +696,18 @@ func formatOraPL(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseInd
 			pKwVal = cTok.AsUpper()
 		}
 
+		if isCursorOrForall || ctVal == ";" {
+			isCursor = false
+			isForall = false
+		}
+
+		switch ctVal {
+		case "AS", "IS":
+			if parensDepth == 0 {
+				isHeader = false
+			}
+		}
+
 		tFormatted = append(tFormatted, cTok)
 	}
 
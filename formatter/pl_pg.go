@@ -1,9 +1,13 @@
 package formatter
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
 
+	"github.com/gsiems/db-dialect/dialect"
 	"github.com/gsiems/sqlfmt/env"
+	"github.com/gsiems/sqlfmt/parser"
 )
 
 // isPgBodyBoundary determines if the supplied string is a boundary marker for
@@ -18,7 +22,100 @@ func isPgBodyBoundary(s string) bool {
 	if len(s) < 2 {
 		return false
 	}
-	return true
+	// As opposed to an entire dollar-quoted string
+	return strings.Count(s, "$") == 2
+}
+
+// splitPgBodies determines which of the dollar-quoted strings in the parsed
+// tokens are PostgreSQL function, procedure, or DO block bodies that can be
+// formatted and splits those into their component tokens. Any other
+// dollar-quoted strings (string literals, bodies for other languages, etc.)
+// are left as opaque strings.
+func splitPgBodies(e *env.Env, tokens []parser.Token) []parser.Token {
+
+	if e.Dialect() != dialect.PostgreSQL {
+		return tokens
+	}
+
+	p := parser.NewParser(e.DialectName())
+
+	var ret []parser.Token
+
+	isRoutine := false // the current statement is a function, procedure, or DO block
+	isDo := false
+	pKwVal := "" // The upper-case value of the previous keyword token
+
+	idxMax := len(tokens) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+
+		cTok := tokens[idx]
+		ctVal := strings.ToUpper(cTok.Value())
+
+		switch {
+		case cTok.Type() == parser.DollarQuoted:
+			if isRoutine && (pKwVal == "AS" || isDo) && isFormattablePgLang(tokens, idx, isDo) {
+				if toks, err := p.SplitDollarQuoted(cTok); err == nil {
+					ret = append(ret, toks...)
+					continue
+				}
+			}
+		case cTok.Type() == parser.SemiColon:
+			isRoutine = false
+			isDo = false
+		case cTok.Category() == parser.Keyword:
+			switch ctVal {
+			case "FUNCTION", "PROCEDURE":
+				switch pKwVal {
+				case "CREATE", "REPLACE":
+					isRoutine = true
+				}
+			case "DO":
+				isRoutine = true
+				isDo = true
+			}
+		}
+
+		if cTok.Category() == parser.Keyword {
+			pKwVal = ctVal
+		}
+
+		ret = append(ret, cTok)
+	}
+
+	return ret
+}
+
+// isFormattablePgLang determines whether or not the language of the function,
+// procedure, or DO block that contains the specified body token is one that
+// can be formatted (sql or plpgsql). As the LANGUAGE clause can come either
+// before or after the body, the entire statement is searched.
+func isFormattablePgLang(tokens []parser.Token, bodyIdx int, isDo bool) bool {
+
+	// Search back to the start of the statement...
+	idxStart := bodyIdx
+	for idxStart > 0 && tokens[idxStart-1].Type() != parser.SemiColon {
+		idxStart--
+	}
+
+	// ...and forward to the end
+	for idx := idxStart; idx < len(tokens); idx++ {
+
+		if tokens[idx].Type() == parser.SemiColon {
+			break
+		}
+
+		if strings.ToUpper(tokens[idx].Value()) == "LANGUAGE" && idx < len(tokens)-1 {
+			switch strings.ToLower(strings.Trim(tokens[idx+1].Value(), "'\"")) {
+			case "sql", "plpgsql":
+				return true
+			}
+			return false
+		}
+	}
+
+	// DO blocks default to plpgsql
+	return isDo
 }
 
 // tagPgPL ensures that the DDL for creating PostgreSQL functions and
@@ -384,6 +481,18 @@ func pgParamLabel(objType, paramLabel string, pTok, cTok, nTok FmtToken) string
 			return ctVal
 		}
 
+	case "DO":
+		// DO blocks keep their original order as the LANGUAGE may be either
+		// before ("DO [ LANGUAGE lang_name ] code") or after the body
+		switch {
+		case ctVal == "DO":
+			return "TYPE"
+		case ctVal == ";":
+			return "FINAL"
+		case isPgBodyBoundary(ctVal), cTok.typeOf == PLxBody:
+			return "BODY"
+		}
+
 	default:
 		switch ptVal {
 		case objType:
@@ -448,7 +557,7 @@ func formatPgPLBodyKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 			"END LOOP", "EXCEPTION", "EXECUTE", "EXISTS", "EXIT", "FETCH",
 			"FOR", "FOREACH", "FOUND", "FROM", "GET", "IF", "IN", "INTO", "IS",
 			"LIKE", "LOOP", "MATERIALIZED", "NEXT", "NOT", "NULL", "OPEN",
-			"OR", "QUERY", "RAISE", "REFRESH", "RETURN", "ROLLBACK", "SETOF",
+			"OR", "PERFORM", "QUERY", "RAISE", "REFRESH", "RETURN", "ROLLBACK", "SETOF",
 			"THEN", "VIEW", "WHEN", "WHILE":
 
 			tokens[idx].SetUpper()
@@ -613,7 +722,7 @@ func formatPgPLBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, base
 			}
 		case "EXECUTE":
 			switch ptVal {
-			case "FOR", "IN":
+			case "FOR", "IN", "QUERY":
 				// nada
 			default:
 				ensureVSpace = true
@@ -858,8 +967,20 @@ func formatPgPLBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, base
 		tFormatted = append(tFormatted, cTok)
 	}
 
+	if e.AlignDeclarations() {
+		alignPLDeclarations(e, tFormatted)
+	}
+
+	if e.FormatDynamicSQL() {
+		formatPgDynamicSQL(e, tFormatted)
+	}
+
 	tFormatted = wrapLines(e, PLxBody, tFormatted)
 
+	if e.FormatDynamicSQL() {
+		indentPgDynamicSQL(e, tFormatted)
+	}
+
 	adjustCommentIndents(bagType, &tFormatted)
 
 	// Replace the mapped tokens with the newly formatted tokens
@@ -899,6 +1020,9 @@ func formatPgPLNonBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, b
 	var tsLabels = []string{"TYPE", "NAME", "EVENT", "TABLE", "FROM",
 		"DEFERRABLE", "REFERENCING", "FOR", "WHEN", "EXECUTE", "FINAL"}
 
+	// DO block labels
+	var dsLabels = []string{"TYPE", "BODY", "FINAL"}
+
 	var params = make(map[string][]FmtToken)
 	paramLabel := ""
 
@@ -933,6 +1057,8 @@ func formatPgPLNonBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, b
 	switch objType {
 	case "TRIGGER":
 		sLabels = tsLabels
+	case "DO":
+		sLabels = dsLabels
 	default:
 		sLabels = psLabels
 	}
@@ -1016,17 +1142,23 @@ func formatPgPLNonBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, b
 		}
 	}
 
+	warnings := formatPgForeignBodies(e, tFormatted)
+
 	// Cleanup extraneous vertical spacing
 	if true {
 		var pTok FmtToken
 		for idx, cTok := range tFormatted {
 
 			if isPgBodyBoundary(cTok.value) {
-				if pTok.AsUpper() == "AS" {
+				// The opening boundary follows the AS (or the DO/LANGUAGE
+				// for DO blocks)
+				if pTok.AsUpper() == "AS" || (objType == "DO" && !pTok.IsPLBag()) {
 					tFormatted[idx].vSpace = 0
 					tFormatted[idx].hSpace = " "
 				}
-			} else if cTok.vSpace > 1 {
+			} else if cTok.vSpace > 1 && idx > 0 {
+				// The first token (the DO of DO blocks) is the start of the
+				// statement and keeps the vertical space preceding it
 				tFormatted[idx].vSpace = 1
 			}
 
@@ -1038,7 +1170,7 @@ func formatPgPLNonBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, b
 			if cTok.value == ";" {
 				if !pTok.HasTrailingComments() {
 					tFormatted[idx].vSpace = 0
-					tFormatted[idx].hSpace = " "
+					tFormatted[idx].hSpace = terminatorHSpace(e)
 				}
 			}
 			// Set the previous token
@@ -1050,6 +1182,177 @@ func formatPgPLNonBody(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, b
 
 	// Replace the mapped tokens with the newly formatted tokens
 	UpsertMappedBag(bagMap, b.typeOf, b.id, "", tFormatted)
+	AddMappedBagWarnings(bagMap, b.typeOf, b.id, warnings)
+}
+
+// pgFormatSpec matches the format() specifiers (%s, %I, %L, %%, %1$I, etc.)
+var pgFormatSpec = regexp.MustCompile(`%(\d+\$)?-?(\d+|\*(\d+\$)?)?[sIL%]`)
+
+// pgDynamicSQLTag determines if the token at idx is a dollar-quoted string
+// literal that is passed to EXECUTE (including RETURN QUERY EXECUTE) or that
+// is the format string for a format() call and, if so, returns the dollar
+// quote tag
+func pgDynamicSQLTag(tokens []FmtToken, idx int) (string, bool) {
+
+	cTok := tokens[idx]
+	if idx == 0 || cTok.typeOf != parser.DollarQuoted {
+		return "", false
+	}
+
+	switch {
+	case tokens[idx-1].AsUpper() == "EXECUTE":
+		// EXECUTE $q$ ... $q$
+	case idx > 1 && tokens[idx-1].value == "(" && tokens[idx-2].AsUpper() == "FORMAT":
+		// format ( $q$ ... $q$, ... )
+	default:
+		return "", false
+	}
+
+	tag := cTok.value[:strings.Index(cTok.value[1:], "$")+2]
+	if len(cTok.value) < len(tag)*2 || !strings.HasSuffix(cTok.value, tag) {
+		// Un-terminated string
+		return "", false
+	}
+	return tag, true
+}
+
+// formatPgDynamicSQL formats the DML in dynamic SQL string literals. The
+// contents of the literal are placed on the lines following the opening tag,
+// indented one level from the line that the literal starts on.
+func formatPgDynamicSQL(e *env.Env, tokens []FmtToken) {
+
+	lineIndents := 0
+
+	for idx := 0; idx < len(tokens); idx++ {
+		if tokens[idx].vSpace > 0 {
+			lineIndents = tokens[idx].indents
+		}
+
+		tag, ok := pgDynamicSQLTag(tokens, idx)
+		if !ok {
+			continue
+		}
+		inner := tokens[idx].value[len(tag) : len(tokens[idx].value)-len(tag)]
+
+		indent := strings.Repeat(e.Indent(), lineIndents+1)
+		z, ok := formatEmbeddedDML(e, inner, indentColumns(indent, indentTabSize(e)))
+		if !ok {
+			continue
+		}
+
+		for i, line := range z {
+			if line != "" {
+				z[i] = indent + line
+			}
+		}
+
+		tokens[idx].value = tag + "\n" + strings.Join(z, "\n") + "\n" + indent + tag
+	}
+}
+
+// indentPgDynamicSQL adjusts the indentation of the formatted dynamic SQL
+// string literals for any changes made to the indentation of the line that
+// the literal starts on by line wrapping
+func indentPgDynamicSQL(e *env.Env, tokens []FmtToken) {
+
+	lineIndents := 0
+
+	for idx := 0; idx < len(tokens); idx++ {
+		if tokens[idx].vSpace > 0 {
+			lineIndents = tokens[idx].indents
+		}
+
+		tag, ok := pgDynamicSQLTag(tokens, idx)
+		if !ok || !strings.HasPrefix(tokens[idx].value, tag+"\n") {
+			continue
+		}
+
+		z := strings.Split(tokens[idx].value, "\n")
+		oldIndent := strings.TrimSuffix(z[len(z)-1], tag)
+		newIndent := strings.Repeat(e.Indent(), lineIndents+1)
+		if oldIndent == newIndent {
+			continue
+		}
+
+		for i := 1; i < len(z); i++ {
+			if strings.HasPrefix(z[i], oldIndent) {
+				z[i] = newIndent + strings.TrimPrefix(z[i], oldIndent)
+			}
+		}
+		tokens[idx].value = strings.Join(z, "\n")
+	}
+}
+
+// formatEmbeddedDML formats the DML contained in a dynamic SQL string and
+// returns the formatted lines. The format() specifiers are preserved by
+// swapping them for placeholder identifiers while formatting. Should the
+// string not look like DML, not parse, contain multi-line string literals,
+// not fit in the width remaining after the indentation, or result in anything
+// other than white-space and case changes then the string is left as is.
+func formatEmbeddedDML(e *env.Env, s string, indentWidth int) ([]string, bool) {
+
+	const phPrefix = "sqlfmt_ph_"
+
+	input := strings.TrimSpace(s)
+	if input == "" || strings.Contains(input, phPrefix) {
+		return nil, false
+	}
+
+	switch strings.ToUpper(strings.Fields(input)[0]) {
+	case "SELECT", "INSERT", "UPDATE", "DELETE", "WITH", "MERGE", "VALUES":
+	default:
+		return nil, false
+	}
+
+	var specs []string
+	input = pgFormatSpec.ReplaceAllStringFunc(input, func(m string) string {
+		specs = append(specs, m)
+		return fmt.Sprintf("%s%d_", phPrefix, len(specs))
+	})
+
+	// Re-indenting the lines of a multi-line string literal would change the
+	// value of the literal
+	p := parser.NewParser(e.DialectName())
+	parsed, err := p.ParseStatements(input)
+	if err != nil {
+		return nil, false
+	}
+	for _, t := range parsed {
+		if t.Category() != parser.Comment && strings.Contains(t.Value(), "\n") {
+			return nil, false
+		}
+	}
+
+	// Should the width remaining after the indentation be less than the
+	// minimum max line length then the string is left as is rather than
+	// being formatted to a width that overruns the max line length
+	lineLen := e.MaxLineLength() - indentWidth
+
+	ne := e.Clone()
+	ne.SetMaxLineLength(lineLen)
+	if ne.MaxLineLength() != lineLen {
+		return nil, false
+	}
+	ne.SetTerminateStatements(false)
+	ne.SetFormatDynamicSQL(false)
+
+	formatted, _, errs := FormatInput(ne, input)
+	if len(errs) > 0 {
+		return nil, false
+	}
+
+	for i := len(specs); i > 0; i-- {
+		formatted = strings.ReplaceAll(formatted, fmt.Sprintf("%s%d_", phPrefix, i), specs[i-1])
+	}
+
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(s), ""))
+	}
+	if normalize(formatted) != normalize(s) {
+		return nil, false
+	}
+
+	return strings.Split(strings.TrimRight(formatted, "\n"), "\n"), true
 }
 
 /*
@@ -106,9 +106,9 @@ func formatSQLiteTriggerKeywords(e *env.Env, tokens []FmtToken) []FmtToken {
 
 	for idx := 0; idx <= idxMax; idx++ {
 		switch tokens[idx].AsUpper() {
-		case "AFTER", "BEFORE", "BEGIN", "DELETE", "EACH", "END", "EXISTS",
-			"FOR", "IF", "INSERT", "INSTEAD OF", "NOT", "OF", "ON", "ROW",
-			"TRIGGER", "UPDATE", "WHEN":
+		case "AFTER", "AND", "BEFORE", "BEGIN", "DELETE", "EACH", "END",
+			"EXISTS", "FOR", "IF", "INSERT", "INSTEAD OF", "IS", "NOT", "NULL",
+			"OF", "ON", "OR", "ROW", "TRIGGER", "UPDATE", "WHEN":
 
 			tokens[idx].SetUpper()
 
@@ -135,6 +135,7 @@ func formatSQLiteTrigger(e *env.Env, bagMap map[string]TokenBag, bagType, bagId,
 	idxMax := len(tokens) - 1
 
 	var tFormatted []FmtToken
+	var pTok FmtToken // The previous token
 	ptVal := ""
 
 	for idx := 0; idx <= idxMax; idx++ {
@@ -152,7 +153,7 @@ func formatSQLiteTrigger(e *env.Env, bagMap map[string]TokenBag, bagType, bagId,
 			default:
 				ensureVSpace = true
 			}
-		case "ON", "FOR", "BEGIN", "END":
+		case "ON", "FOR", "WHEN", "BEGIN", "END":
 			ensureVSpace = true
 		}
 
@@ -160,16 +161,22 @@ func formatSQLiteTrigger(e *env.Env, bagMap map[string]TokenBag, bagType, bagId,
 
 		switch cTok.AsUpper() {
 		case "BEFORE", "AFTER", "INSTEAD OF", "DELETE", "INSERT", "UPDATE",
-			"FOR", "ON":
+			"FOR", "ON", "WHEN":
 			if cTok.vSpace > 0 {
 				cTok.AdjustIndents(1)
 			} else {
 				cTok.hSpace = " "
 			}
+		default:
+			if idx > 0 && cTok.vSpace == 0 && cTok.hSpace == "" {
+				// tokens that were on a line of their own
+				cTok.AdjustHSpace(e, pTok)
+			}
 		}
 
 		// Set the various "previous token" values
 		ptVal = cTok.AsUpper()
+		pTok = cTok
 
 		tFormatted = append(tFormatted, cTok)
 	}
@@ -1,12 +1,16 @@
 package formatter
 
 import (
+	"strings"
+
 	"github.com/gsiems/db-dialect/dialect"
 	"github.com/gsiems/sqlfmt/env"
 )
 
-// tagPLx ensures that blocks of PL (functions, procedures for PostgreSQL and
-// functions, procedures, and packaged for Oracle) are properly tagged
+// tagPLx ensures that blocks of PL (functions, procedures for PostgreSQL,
+// functions, procedures, and packaged for Oracle, stored programs for
+// MySQL/MariaDB, and functions, procedures, triggers, and batches for MS
+// SQL-Server) are properly tagged
 func tagPLx(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 	switch e.Dialect() {
 	case dialect.PostgreSQL:
@@ -15,6 +19,10 @@ func tagPLx(e *env.Env, m []FmtToken, bagMap map[string]TokenBag) []FmtToken {
 		return tagSQLiteTrigger(e, m, bagMap)
 	case dialect.Oracle:
 		return tagOraPL(m, bagMap)
+	case dialect.MySQL, dialect.MariaDB:
+		return tagMySQLPL(e, m, bagMap)
+	case dialect.MSSQL:
+		return tagMSSQLPL(e, m, bagMap)
 	}
 	return m
 }
@@ -28,5 +36,183 @@ func formatPLxBag(e *env.Env, bagMap map[string]TokenBag, bagType, bagId, baseIn
 		formatSQLiteTrigger(e, bagMap, bagType, bagId, baseIndents, forceInitVSpace)
 	case dialect.Oracle:
 		formatOraPL(e, bagMap, bagType, bagId, baseIndents, forceInitVSpace)
+	case dialect.MySQL, dialect.MariaDB:
+		formatMySQLPL(e, bagMap, bagType, bagId, baseIndents, forceInitVSpace)
+	case dialect.MSSQL:
+		formatMSSQLPL(e, bagMap, bagType, bagId, baseIndents, forceInitVSpace)
+	}
+}
+
+// plDecl is a variable declaration in a PL declaration section, split into
+// the indices of the tokens that start each column of the declaration:
+//
+//	name [CONSTANT] type [NOT NULL] [{ DEFAULT | := | = } expression] ;
+type plDecl struct {
+	idxName     int
+	idxConstant int // -1 if not CONSTANT
+	idxType     int
+	idxNotNull  int // -1 if not NOT NULL
+	idxDefault  int // -1 if there is no default
+	idxEnd      int // the index of the token that ends the declaration
+	widths      [3]int
+}
+
+// alignPLDeclarations aligns the names, CONSTANT keywords, data types, NOT
+// NULL constraints, and default assignments of the variable declarations in
+// DECLARE sections into columns. Each run of consecutive single-line
+// declarations is aligned separately. Declarations that do not fit the
+// pattern (cursors, aliases, wrapped declarations, etc.) end the run.
+func alignPLDeclarations(e *env.Env, tokens []FmtToken) {
+
+	var run []plDecl
+	inDeclare := false
+	idxMax := len(tokens) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+		switch tokens[idx].AsUpper() {
+		case "DECLARE":
+			inDeclare = true
+			continue
+		case "BEGIN":
+			alignPLDeclRun(tokens, run)
+			run = nil
+			inDeclare = false
+			continue
+		}
+
+		if !inDeclare || tokens[idx].vSpace == 0 {
+			continue
+		}
+
+		d, ok := parsePLDecl(tokens, idx)
+		if !ok || tokens[idx].vSpace > 1 {
+			alignPLDeclRun(tokens, run)
+			run = nil
+		}
+		if ok {
+			run = append(run, d)
+			idx = d.idxEnd
+		}
+	}
+	alignPLDeclRun(tokens, run)
+}
+
+// parsePLDecl attempts to parse the variable declaration that starts at idx
+func parsePLDecl(tokens []FmtToken, idx int) (plDecl, bool) {
+
+	d := plDecl{
+		idxName:     idx,
+		idxConstant: -1,
+		idxType:     -1,
+		idxNotNull:  -1,
+		idxDefault:  -1,
+		idxEnd:      -1,
+	}
+
+	col := 0
+	for i := idx; i < len(tokens); i++ {
+		cTok := tokens[i]
+
+		if i > idx {
+			if cTok.vSpace > 0 || cTok.IsBag() {
+				return d, false
+			}
+			if cTok.value == ";" {
+				d.idxEnd = i
+				break
+			}
+		}
+		if strings.Contains(cTok.value, "\n") {
+			return d, false
+		}
+
+		ctVal := cTok.AsUpper()
+		switch {
+		case i == idx:
+			// the name
+		case i == idx+1 && ctVal == "CONSTANT":
+			d.idxConstant = i
+		case d.idxType < 0:
+			switch ctVal {
+			case "ALIAS", "CURSOR", "NO", "SCROLL", "NOT", "DEFAULT", ":=", "=":
+				return d, false
+			}
+			d.idxType = i
+			col = 1
+		case d.idxDefault < 0 && d.idxNotNull < 0 && ctVal == "NOT" && i < len(tokens)-1 && tokens[i+1].AsUpper() == "NULL":
+			d.idxNotNull = i
+			col = 2
+		case d.idxDefault < 0:
+			switch ctVal {
+			case "DEFAULT", ":=", "=":
+				d.idxDefault = i
+				// the default is the last column so the width is not needed
+				col = 3
+			}
+		}
+
+		if col < 3 && i != d.idxType && i != d.idxNotNull && i != d.idxConstant && i > idx {
+			d.widths[col] += displayWidth(cTok.hSpace)
+		}
+		if col < 3 && i != d.idxConstant {
+			d.widths[col] += displayWidth(cTok.value)
+		}
+	}
+
+	return d, d.idxEnd > 0 && d.idxType > 0
+}
+
+// alignPLDeclRun aligns the columns of a run of declarations
+func alignPLDeclRun(tokens []FmtToken, run []plDecl) {
+
+	if len(run) < 2 {
+		return
+	}
+
+	var colWidths [3]int
+	hasConstant := false
+	hasNotNull := false
+	for _, d := range run {
+		for i, w := range d.widths {
+			colWidths[i] = max(colWidths[i], w)
+		}
+		hasConstant = hasConstant || d.idxConstant > 0
+		hasNotNull = hasNotNull || d.idxNotNull > 0
+	}
+
+	constWidth := 0
+	if hasConstant {
+		constWidth = len("CONSTANT") + 1
+	}
+
+	// the starting column (relative to the start of the line) of each column
+	colType := colWidths[0] + 1 + constWidth
+	colNotNull := colType + colWidths[1] + 1
+	colDefault := colNotNull
+	if hasNotNull {
+		colDefault += colWidths[2] + 1
+	}
+
+	pad := func(idx, col, end int) {
+		tokens[idx].hSpace = strings.Repeat(" ", max(col-end, 1))
+	}
+
+	for _, d := range run {
+
+		end := d.widths[0]
+		if d.idxConstant > 0 {
+			pad(d.idxConstant, colWidths[0]+1, end)
+			end = colType - 1
+		}
+		pad(d.idxType, colType, end)
+
+		end = colType + d.widths[1]
+		if d.idxNotNull > 0 {
+			pad(d.idxNotNull, colNotNull, end)
+			end = colNotNull + d.widths[2]
+		}
+		if d.idxDefault > 0 {
+			pad(d.idxDefault, colDefault, end)
+		}
 	}
 }
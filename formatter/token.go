@@ -35,6 +35,12 @@ func (t *CmtToken) AdjustIndents(i int) {
 	}
 }
 
+// LimitVSpace restricts the vertical space preceding the comment to no more
+// than maxBlankLines blank lines
+func (t *CmtToken) LimitVSpace(maxBlankLines int) {
+	t.vSpace = limitVSpace(t.vSpace, t.vSpaceOrig, maxBlankLines)
+}
+
 type FmtToken struct {
 	id          int        // the ID of the token
 	categoryOf  int        // the category of token
@@ -87,6 +93,17 @@ func (t *FmtToken) IsCodeComment() bool {
 	return t.categoryOf == parser.Comment
 }
 
+func (t *FmtToken) IsData() bool {
+	return t.categoryOf == parser.Data
+}
+
+// IsTerminator returns true if the token is a statement terminator. This is
+// usually a semi-colon but, for MySQL/MariaDB, may also be the delimiter set
+// by a DELIMITER command.
+func (t *FmtToken) IsTerminator() bool {
+	return t.value == ";" || t.typeOf == parser.SemiColon
+}
+
 func (t *FmtToken) IsDatatype() bool {
 	return t.categoryOf == parser.Datatype
 }
@@ -100,7 +117,7 @@ func (t *FmtToken) IsKeyword() bool {
 }
 
 func (t *FmtToken) IsLabel() bool {
-	return t.categoryOf == parser.Label
+	return t.typeOf == parser.Label
 }
 
 func (t *FmtToken) IsPLBag() bool {
@@ -149,6 +166,11 @@ func (t *FmtToken) AdjustHSpace(e *env.Env, pTok FmtToken) {
 		return
 	}
 
+	if t.IsTerminator() {
+		t.hSpace = terminatorHSpace(e)
+		return
+	}
+
 	switch t.value {
 	case ",", "..", "[", "]":
 		t.hSpace = ""
@@ -160,6 +182,11 @@ func (t *FmtToken) AdjustHSpace(e *env.Env, pTok FmtToken) {
 		return
 	}
 
+	if !e.ParenPadding() && (t.value == ")" || pTok.value == "(") {
+		t.hSpace = ""
+		return
+	}
+
 	if len(pTok.value) > 0 {
 
 		switch e.Dialect() {
@@ -189,7 +216,7 @@ func (t *FmtToken) AdjustHSpace(e *env.Env, pTok FmtToken) {
 			return
 		}
 
-		if pTok.IsIdentifier() && strings.HasPrefix(t.value, ".") {
+		if (pTok.IsIdentifier() || pTok.typeOf == parser.BindParameter) && strings.HasPrefix(t.value, ".") {
 			t.hSpace = ""
 			return
 		}
@@ -209,6 +236,15 @@ func (t *FmtToken) AdjustHSpace(e *env.Env, pTok FmtToken) {
 	t.hSpace = " "
 }
 
+// terminatorHSpace returns the horizontal space to place before a statement
+// terminator
+func terminatorHSpace(e *env.Env) string {
+	if e.SpaceBeforeTerminator() {
+		return " "
+	}
+	return ""
+}
+
 func (t *FmtToken) AdjustVSpace(ensureVSpace, honorVSpace bool) {
 	switch {
 	//case t.id == 0:
@@ -253,6 +289,28 @@ func (t *FmtToken) HonorVSpace() {
 	}
 }
 
+// LimitVSpace restricts the vertical space preceding the token to no more
+// than maxBlankLines blank lines
+func (t *FmtToken) LimitVSpace(maxBlankLines int) {
+	t.vSpace = limitVSpace(t.vSpace, t.vSpaceOrig, maxBlankLines)
+}
+
+// limitVSpace determines the vertical space to use given the currently set
+// vertical space, the original vertical space, and the maximum number of
+// blank lines allowed. HonorVSpace and EnsureVSpace allow for at most one
+// blank line so, should more than one blank line be allowed, the original
+// vertical space is used for those tokens that are already preceded by a
+// blank line.
+func limitVSpace(vSpace, vSpaceOrig, maxBlankLines int) int {
+	if vSpace > 1 && vSpaceOrig > vSpace {
+		vSpace = min(vSpaceOrig, maxBlankLines+1)
+	}
+	if vSpace > maxBlankLines+1 {
+		vSpace = maxBlankLines + 1
+	}
+	return vSpace
+}
+
 func (t *FmtToken) SetUpper() {
 	if t.value != strings.ToUpper(t.value) {
 		t.value = strings.ToUpper(t.value)
@@ -282,32 +340,38 @@ func (t *FmtToken) SetKeywordCase(e *env.Env, kWords []string) {
 func nameOf(i int) string {
 
 	var names = map[int]string{
-		parser.Other:            "Other",
-		parser.WhiteSpace:       "WhiteSpace",
-		parser.Identifier:       "Identifier",
-		parser.Numeric:          "Numeric",
-		parser.Comment:          "Comment",
-		parser.LineComment:      "LineComment",
-		parser.PoundLineComment: "PoundLineComment",
-		parser.BlockComment:     "BlockComment",
-		parser.SingleQuoted:     "SingleQuoted",
-		parser.DoubleQuoted:     "DoubleQuoted",
-		parser.BacktickQuoted:   "BacktickQuoted",
-		parser.BracketQuoted:    "BracketQuoted",
-		parser.Label:            "Label",
-		parser.Keyword:          "Keyword",
-		parser.Operator:         "Operator",
-		parser.BindParameter:    "BindParameter",
-		parser.OpenParen:        "OpenParen",
-		parser.CloseParen:       "CloseParen",
-		parser.Comma:            "Comma",
-		parser.SemiColon:        "SemiColon",
-		parser.Datatype:         "Datatype",
-		parser.Literal:          "Literal",
-		parser.String:           "String",
-		parser.Punctuation:      "Punctuation",
-		parser.End:              "End",
-		parser.Data:             "Data",
+		parser.Other:             "Other",
+		parser.WhiteSpace:        "WhiteSpace",
+		parser.Identifier:        "Identifier",
+		parser.Numeric:           "Numeric",
+		parser.Comment:           "Comment",
+		parser.LineComment:       "LineComment",
+		parser.PoundLineComment:  "PoundLineComment",
+		parser.BlockComment:      "BlockComment",
+		parser.SingleQuoted:      "SingleQuoted",
+		parser.DoubleQuoted:      "DoubleQuoted",
+		parser.BacktickQuoted:    "BacktickQuoted",
+		parser.BracketQuoted:     "BracketQuoted",
+		parser.DollarQuoted:      "DollarQuoted",
+		parser.BitString:         "BitString",
+		parser.HexString:         "HexString",
+		parser.EscapeString:      "EscapeString",
+		parser.UnicodeString:     "UnicodeString",
+		parser.UnicodeIdentifier: "UnicodeIdentifier",
+		parser.Label:             "Label",
+		parser.Keyword:           "Keyword",
+		parser.Operator:          "Operator",
+		parser.BindParameter:     "BindParameter",
+		parser.OpenParen:         "OpenParen",
+		parser.CloseParen:        "CloseParen",
+		parser.Comma:             "Comma",
+		parser.SemiColon:         "SemiColon",
+		parser.Datatype:          "Datatype",
+		parser.Literal:           "Literal",
+		parser.String:            "String",
+		parser.Punctuation:       "Punctuation",
+		parser.End:               "End",
+		parser.Data:              "Data",
 
 		// Token bag types/categories
 		DNFBag:       "DNFBag",
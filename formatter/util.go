@@ -1,6 +1,10 @@
 package formatter
 
-import "log"
+import (
+	"log"
+	"unicode"
+	"unicode/utf8"
+)
 
 type intStack struct {
 	ints []int
@@ -81,3 +85,132 @@ func getBId(bagIds map[int]int, parensDepth int) int {
 	}
 	return testId
 }
+
+// displayWidth returns the number of columns needed to display a string.
+// East Asian wide and full-width characters take two columns while combining
+// marks and other zero-width characters take none.
+func displayWidth(s string) int {
+
+	// The common case
+	if isASCII(s) {
+		return len(s)
+	}
+
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// runeWidth returns the number of columns needed to display a character
+func runeWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return 1
+	case r == 0x200B, r == 0x200C, r == 0x200D, r == 0x2060, r == 0xFEFF:
+		// zero-width spaces and joiners
+		return 0
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWideRune(r):
+		return 2
+	}
+	return 1
+}
+
+// wideRanges are the (approximate) ranges of the East Asian Wide (W) and
+// Full-width (F) characters
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},   // Hangul Jamo initial consonants
+	{0x231A, 0x231B},   // watch, hourglass
+	{0x2329, 0x232A},   // angle brackets
+	{0x23E9, 0x23EC},   // media control symbols
+	{0x23F0, 0x23F0},   // alarm clock
+	{0x23F3, 0x23F3},   // hourglass
+	{0x25FD, 0x25FE},   // small squares
+	{0x2614, 0x2615},   // umbrella, hot beverage
+	{0x2648, 0x2653},   // zodiac
+	{0x267F, 0x267F},   // wheelchair
+	{0x2693, 0x2693},   // anchor
+	{0x26A1, 0x26A1},   // high voltage
+	{0x26AA, 0x26AB},   // circles
+	{0x26BD, 0x26BE},   // soccer ball, baseball
+	{0x26C4, 0x26C5},   // snowman, sun behind cloud
+	{0x26CE, 0x26CE},   // ophiuchus
+	{0x26D4, 0x26D4},   // no entry
+	{0x26EA, 0x26EA},   // church
+	{0x26F2, 0x26F3},   // fountain, golf
+	{0x26F5, 0x26F5},   // sailboat
+	{0x26FA, 0x26FA},   // tent
+	{0x26FD, 0x26FD},   // fuel pump
+	{0x2705, 0x2705},   // check mark
+	{0x270A, 0x270B},   // raised fist, hand
+	{0x2728, 0x2728},   // sparkles
+	{0x274C, 0x274C},   // cross mark
+	{0x274E, 0x274E},   // cross mark
+	{0x2753, 0x2755},   // question marks
+	{0x2757, 0x2757},   // exclamation mark
+	{0x2795, 0x2797},   // math symbols
+	{0x27B0, 0x27B0},   // curly loop
+	{0x27BF, 0x27BF},   // double curly loop
+	{0x2B1B, 0x2B1C},   // large squares
+	{0x2B50, 0x2B50},   // star
+	{0x2B55, 0x2B55},   // circle
+	{0x2E80, 0x303E},   // CJK radicals, Kangxi, CJK symbols and punctuation
+	{0x3041, 0x33FF},   // Hiragana, Katakana, Bopomofo, Hangul compatibility Jamo, etc.
+	{0x3400, 0x4DBF},   // CJK unified ideographs extension A
+	{0x4E00, 0x9FFF},   // CJK unified ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xA960, 0xA97F},   // Hangul Jamo extended-A
+	{0xAC00, 0xD7A3},   // Hangul syllables
+	{0xF900, 0xFAFF},   // CJK compatibility ideographs
+	{0xFE10, 0xFE19},   // vertical forms
+	{0xFE30, 0xFE6F},   // CJK compatibility forms, small form variants
+	{0xFF00, 0xFF60},   // full-width forms
+	{0xFFE0, 0xFFE6},   // full-width signs
+	{0x16FE0, 0x16FE4}, // ideographic symbols and punctuation
+	{0x17000, 0x18CFF}, // Tangut
+	{0x1B000, 0x1B2FF}, // Kana supplement, Nushu
+	{0x1F004, 0x1F004}, // mahjong tile
+	{0x1F0CF, 0x1F0CF}, // playing card
+	{0x1F18E, 0x1F18E}, // negative squared AB
+	{0x1F191, 0x1F19A}, // squared latin letters
+	{0x1F200, 0x1F251}, // enclosed ideographic supplement
+	{0x1F300, 0x1F64F}, // misc symbols and pictographs, emoticons
+	{0x1F680, 0x1F6FF}, // transport and map symbols
+	{0x1F900, 0x1F9FF}, // supplemental symbols and pictographs
+	{0x1FA70, 0x1FAFF}, // symbols and pictographs extended-A
+	{0x20000, 0x2FFFD}, // CJK unified ideographs extensions B-F
+	{0x30000, 0x3FFFD}, // CJK unified ideographs extension G+
+}
+
+func isWideRune(r rune) bool {
+
+	if r < wideRanges[0][0] {
+		return false
+	}
+
+	lo, hi := 0, len(wideRanges)-1
+	for lo <= hi {
+		mid := (lo + hi) / 2
+		switch {
+		case r < wideRanges[mid][0]:
+			hi = mid - 1
+		case r > wideRanges[mid][1]:
+			lo = mid + 1
+		default:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,471 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/gsiems/sqlfmt/env"
+)
+
+// The "optimal" line wrapping engine.
+//
+// Rather than applying a fixed sequence of wrapping passes, the tokens for a
+// line are first converted to a document of nested groups (in the style of
+// Wadler/Oppen pretty printers) where each group contains text and the
+// (potential) line breaks that belong to it:
+//
+//   - parenthesized expressions are groups that may break after the opening
+//     parens,
+//   - comma separated lists are groups that may break after the commas,
+//   - list elements are groups that may break before logical operators, and
+//   - terms are groups that may break before comparison, math, and
+//     concatenation operators.
+//
+// Each group is then laid out flat (no breaks), filled (break only as
+// needed), or broken (break at every opportunity), choosing whichever mode
+// minimizes the layout cost. The cost penalizes line overflow most heavily,
+// followed by the number of lines added, and then by the parens depth at
+// which lines are added (breaking the outer groups is preferred to breaking
+// the inner groups).
+
+const (
+	docText = iota + 500
+	docLine
+	docGroup
+	groupFlat
+	groupFill
+	groupBreak
+)
+
+const (
+	costOverflow = 1000 // the cost for each character past the max line length
+	costLine     = 10   // the cost for each line added
+	costDepth    = 1    // the cost for each parens depth level of an added line
+)
+
+// wrapDoc is a node in the document tree for a line of tokens
+type wrapDoc struct {
+	kind     int        // docText, docLine, or docGroup
+	idx      int        // the index of the token (docText, docLine)
+	width    int        // the width when not broken (docText, docLine)
+	indents  int        // the indentation to use when the line is broken (docLine)
+	depth    int        // the parens depth of the line (docLine)
+	hard     bool       // the token is already at the start of a line (docLine)
+	children []*wrapDoc // the child nodes (docGroup)
+}
+
+// docKey identifies the layout of a group at a starting column for a given
+// trailing width
+type docKey struct {
+	d     *wrapDoc
+	col   int
+	trail int
+}
+
+// docLayout is the result of laying out a group
+type docLayout struct {
+	mode int // the group mode that was chosen
+	cost int // the cost of the lines ended within the group
+	col  int // the column at the end of the group
+}
+
+// docBuilder converts a line of tokens into a document tree
+type docBuilder struct {
+	e         *env.Env
+	bagType   int
+	tokens    []FmtToken
+	idx       int    // the index of the current token
+	base      int    // the indentation of the current line
+	depth     int    // the current parens depth
+	lineDepth int    // the parens depth at the start of the current line
+	pKwVal    string // the upper-case value of the previous keyword token
+	hasLine   bool   // a line node was added for the current token
+}
+
+// docEngine lays out the document tree for a line of tokens
+type docEngine struct {
+	e      *env.Env
+	tokens []FmtToken
+	layout map[docKey]docLayout
+	widths map[*wrapDoc]int
+}
+
+// wrapLineOptimal takes "one lines worth" of tokens and adds the line breaks
+// that result in the lowest cost layout
+func wrapLineOptimal(e *env.Env, bagType int, tokens []FmtToken) []FmtToken {
+	if len(tokens) == 0 {
+		return tokens
+	}
+
+	b := docBuilder{
+		e:       e,
+		bagType: bagType,
+		tokens:  tokens,
+		base:    calcIndent(bagType, tokens[0]),
+	}
+
+	root := b.parseList()
+
+	de := docEngine{
+		e:      e,
+		tokens: tokens,
+		layout: make(map[docKey]docLayout),
+		widths: make(map[*wrapDoc]int),
+	}
+
+	de.apply(root, 0, 0)
+
+	return tokens
+}
+
+////////////////////////////////////////////////////////////////////////
+// Building the document
+
+func (b *docBuilder) atEnd() bool {
+	return b.idx >= len(b.tokens)
+}
+
+func (b *docBuilder) atListEnd() bool {
+	if b.atEnd() {
+		return true
+	}
+	switch b.tokens[b.idx].value {
+	case ",":
+		return true
+	case ")":
+		return b.depth > 0
+	}
+	return false
+}
+
+// newLine creates the line node for the current token. The line is hard if
+// the token already starts a line, soft (may be broken) if requested, and
+// nil otherwise.
+func (b *docBuilder) newLine(soft bool, offset int) *wrapDoc {
+
+	cTok := b.tokens[b.idx]
+
+	if b.idx > 0 && cTok.vSpace > 0 {
+		b.base = calcIndent(b.bagType, cTok)
+		b.lineDepth = b.depth
+		return &wrapDoc{kind: docLine, idx: b.idx, hard: true}
+	}
+
+	if !soft || b.idx == 0 {
+		return nil
+	}
+
+	return &wrapDoc{
+		kind:    docLine,
+		idx:     b.idx,
+		width:   displayWidth(cTok.hSpace),
+		indents: b.base + max(b.depth-b.lineDepth, 0) + offset,
+		depth:   b.depth,
+	}
+}
+
+// take adds the current token, and any line that precedes it, to the group
+func (b *docBuilder) take(g *wrapDoc, soft bool, offset int) {
+
+	b.appendLine(g, soft, offset)
+	b.takeFirst(g)
+}
+
+// takeFirst adds the first token of a group (whose preceding line, if any,
+// belongs to the enclosing group)
+func (b *docBuilder) takeFirst(g *wrapDoc) {
+
+	cTok := b.tokens[b.idx]
+
+	// when the token starts a line then the indentation is accounted for by
+	// the line node
+	width := calcLen(b.e, cTok)
+	if b.hasLine {
+		width = displayWidth(cTok.value)
+	}
+
+	g.children = append(g.children, &wrapDoc{kind: docText, idx: b.idx, width: width})
+
+	if cTok.IsKeyword() {
+		b.pKwVal = cTok.AsUpper()
+	}
+	b.hasLine = false
+	b.idx++
+}
+
+// appendLine adds the line (if any) for the current token to the group
+func (b *docBuilder) appendLine(g *wrapDoc, soft bool, offset int) {
+	if ln := b.newLine(soft, offset); ln != nil {
+		g.children = append(g.children, ln)
+		b.hasLine = true
+	}
+}
+
+// parseList creates the group for a list of comma separated elements
+func (b *docBuilder) parseList() *wrapDoc {
+
+	g := &wrapDoc{kind: docGroup}
+
+	for !b.atEnd() {
+		if b.tokens[b.idx].value == ")" && b.depth > 0 {
+			break
+		}
+
+		if len(g.children) > 0 {
+			b.appendLine(g, true, 0)
+		}
+		g.children = append(g.children, b.parseElement())
+
+		if b.atEnd() || b.tokens[b.idx].value != "," {
+			break
+		}
+		b.take(g, false, 0)
+	}
+	return g
+}
+
+// parseElement creates the group for a list element. List elements may be
+// broken before the logical operators.
+func (b *docBuilder) parseElement() *wrapDoc {
+
+	g := &wrapDoc{kind: docGroup}
+
+	for !b.atListEnd() {
+		if len(g.children) > 0 {
+			b.appendLine(g, true, 1)
+		}
+		g.children = append(g.children, b.parseTerm())
+	}
+	return g
+}
+
+// parseTerm creates the group for a term. Terms may be broken before the
+// comparison, math, and concatenation operators.
+func (b *docBuilder) parseTerm() *wrapDoc {
+
+	g := &wrapDoc{kind: docGroup}
+
+	for !b.atListEnd() {
+
+		cTok := b.tokens[b.idx]
+		first := len(g.children) == 0
+
+		switch {
+		case !first && isLogical(b.pKwVal, cTok):
+			return g
+		case cTok.value == "(":
+			if !first {
+				b.appendLine(g, false, 0)
+			}
+			g.children = append(g.children, b.parseParens())
+		case first:
+			b.takeFirst(g)
+		default:
+			b.take(g, b.canBreakBefore(), 1)
+		}
+	}
+	return g
+}
+
+// parseParens creates the group for a parenthesized expression. These may be
+// broken after the opening parens.
+func (b *docBuilder) parseParens() *wrapDoc {
+
+	g := &wrapDoc{kind: docGroup}
+
+	b.takeFirst(g)
+	b.depth++
+
+	if !b.atEnd() && b.tokens[b.idx].value != ")" {
+		b.appendLine(g, true, 0)
+		g.children = append(g.children, b.parseList())
+	}
+
+	if !b.atEnd() && b.tokens[b.idx].value == ")" {
+		b.take(g, false, 0)
+	}
+	b.depth--
+
+	return g
+}
+
+// canBreakBefore determines if a line break may be placed before the
+// current token (which is not the first token of a term)
+func (b *docBuilder) canBreakBefore() bool {
+
+	cTok := b.tokens[b.idx]
+	if !isOperator(0, cTok) {
+		return false
+	}
+
+	// avoid unary operators and "*" as a wildcard
+	pTok := b.tokens[b.idx-1]
+	switch {
+	case isOperator(0, pTok), pTok.IsKeyword():
+		return false
+	}
+	switch pTok.value {
+	case "(", ",", ".":
+		return false
+	}
+	if cTok.value == "*" && b.idx < len(b.tokens)-1 {
+		switch b.tokens[b.idx+1].value {
+		case ")", ",", ".":
+			return false
+		}
+	}
+	return true
+}
+
+////////////////////////////////////////////////////////////////////////
+// Laying out the document
+
+func (de *docEngine) overflow(col int) int {
+	return max(col-de.e.MaxLineLength(), 0) * costOverflow
+}
+
+func (de *docEngine) indentWidth(indents int) int {
+	return len(strings.Repeat(de.e.Indent(), indents))
+}
+
+// flatWidth determines the width of a node when it is not broken, up to the
+// first hard line (if any)
+func (de *docEngine) flatWidth(d *wrapDoc) (int, bool) {
+
+	switch d.kind {
+	case docText:
+		return d.width, false
+	case docLine:
+		return d.width, d.hard
+	}
+
+	if w, ok := de.widths[d]; ok {
+		return w, false
+	}
+
+	width := 0
+	for _, c := range d.children {
+		w, hard := de.flatWidth(c)
+		width += w
+		if hard {
+			return width, true
+		}
+	}
+	de.widths[d] = width
+	return width, false
+}
+
+// trailWidth determines the width of the text that follows the idx child of
+// the group up to the next line
+func (de *docEngine) trailWidth(g *wrapDoc, idx, trail int) int {
+
+	width := 0
+	for _, c := range g.children[idx+1:] {
+		if c.kind == docLine {
+			return width
+		}
+		w, hard := de.flatWidth(c)
+		width += w
+		if hard {
+			return width
+		}
+	}
+	return width + trail
+}
+
+// best determines the lowest cost layout for the group starting at the
+// column where trail is the width of the text following the group
+func (de *docEngine) best(g *wrapDoc, col, trail int) docLayout {
+
+	key := docKey{d: g, col: col, trail: trail}
+	if l, ok := de.layout[key]; ok {
+		return l
+	}
+
+	hasSoft := false
+	for _, c := range g.children {
+		if c.kind == docLine && !c.hard {
+			hasSoft = true
+			break
+		}
+	}
+
+	modes := []int{groupFlat}
+	if hasSoft {
+		modes = append(modes, groupFill, groupBreak)
+	}
+
+	var ret docLayout
+	bestCost := -1
+	for _, mode := range modes {
+		l := de.run(g, mode, col, trail, false)
+		cost := l.cost + de.overflow(l.col+trail)
+		if bestCost < 0 || cost < bestCost {
+			bestCost = cost
+			ret = l
+		}
+	}
+
+	de.layout[key] = ret
+	return ret
+}
+
+// apply adds the line breaks for the lowest cost layout of the group
+func (de *docEngine) apply(g *wrapDoc, col, trail int) docLayout {
+	l := de.best(g, col, trail)
+	return de.run(g, l.mode, col, trail, true)
+}
+
+// run lays out the group using the specified mode
+func (de *docEngine) run(g *wrapDoc, mode, col, trail int, doApply bool) docLayout {
+
+	cost := 0
+
+	for idx, c := range g.children {
+		switch c.kind {
+		case docText:
+			col += c.width
+
+		case docLine:
+			addBreak := c.hard
+			if !c.hard {
+				switch mode {
+				case groupBreak:
+					addBreak = true
+				case groupFill:
+					addBreak = col+c.width+de.trailWidth(g, idx, trail) > de.e.MaxLineLength()
+				}
+			}
+
+			if !addBreak {
+				col += c.width
+				continue
+			}
+
+			cost += de.overflow(col)
+			if c.hard {
+				col = de.indentWidth(de.tokens[c.idx].indents)
+				continue
+			}
+
+			cost += costLine + costDepth*c.depth
+			col = de.indentWidth(c.indents)
+			if doApply {
+				de.tokens[c.idx].EnsureVSpace()
+				de.tokens[c.idx].AdjustIndents(c.indents)
+			}
+
+		case docGroup:
+			ct := de.trailWidth(g, idx, trail)
+			var l docLayout
+			if doApply {
+				l = de.apply(c, col, ct)
+			} else {
+				l = de.best(c, col, ct)
+			}
+			cost += l.cost
+			col = l.col
+		}
+	}
+
+	return docLayout{mode: mode, cost: cost, col: col}
+}
@@ -138,14 +138,20 @@ func adjLogicalCnt(logicalCnt int, pKwVal string, cTok FmtToken) int {
 	return logicalCnt
 }
 
-// calcLen calculates the length of a token
+// calcLen calculates the length of a token as displayed (wide characters
+// count as two columns)
 func calcLen(e *env.Env, cTok FmtToken) int {
 	// and if token is a pointer to a bag?
 
+	if i := strings.LastIndex(cTok.value, "\n"); i >= 0 {
+		// for multi-line tokens only the last line continues the line
+		return displayWidth(cTok.value[i+1:])
+	}
+
 	if cTok.vSpace > 0 {
-		return len(strings.Repeat(e.Indent(), cTok.indents)) + len(cTok.value)
+		return len(strings.Repeat(e.Indent(), cTok.indents)) + displayWidth(cTok.value)
 	}
-	return len(cTok.hSpace) + len(cTok.value)
+	return displayWidth(cTok.hSpace) + displayWidth(cTok.value)
 }
 
 func calcSliceLen(e *env.Env, bagType int, tokens []FmtToken) int {
@@ -240,6 +246,11 @@ func wrapLines(e *env.Env, bagType int, tokens []FmtToken) (ret []FmtToken) {
 		tokens = wrapDMLCase(e, bagType, tokens)
 		tokens = wrapDMLLogical(e, bagType, tokens)
 
+	case DDLBag, DCLBag:
+		tokens = wrapDDLClauses(e, bagType, tokens)
+		tokens = wrapDMLCase(e, bagType, tokens)
+		tokens = wrapDMLLogical(e, bagType, tokens)
+
 	case PLxBody:
 		tokens = wrapPLxCalls(e, bagType, maxParensDepth, tokens)
 		tokens = wrapPLxCase(e, bagType, tokens)
@@ -281,6 +292,10 @@ func wrapLine(e *env.Env, bagType, mxPd int, tokens []FmtToken) []FmtToken {
 		return tokens
 	}
 
+	if e.WrapEngine() == env.WrapOptimal {
+		return wrapLineOptimal(e, bagType, tokens)
+	}
+
 	// A work in progress...
 	// Order matters but may be/is probably context specific...
 	// Maybe consider the original vSpace for operators
@@ -291,7 +306,12 @@ func wrapLine(e *env.Env, bagType, mxPd int, tokens []FmtToken) []FmtToken {
 
 	for pdl := 0; pdl <= mxPd; pdl++ {
 
-		tokens = wrapOnCommasY(e, bagType, pdl, tokens)
+		switch bagType {
+		case DDLBag, DCLBag:
+			// DDL and DCL lists are only wrapped when they are too long
+		default:
+			tokens = wrapOnCommasY(e, bagType, pdl, tokens)
+		}
 
 		tokens = wrapOnCommasX(e, bagType, pdl, tokens)
 		tokens = wrapOnCompOps(e, bagType, pdl, tokens)
@@ -791,7 +811,7 @@ func wrapDMLLogical(e *env.Env, bagType int, tokens []FmtToken) []FmtToken {
 				switch {
 				case lineLen > e.MaxLineLength():
 					addBreaks = true
-				case lCnt > 2:
+				case lCnt > 2 && bagType == DMLBag:
 					addBreaks = true
 				}
 			}
@@ -825,7 +845,7 @@ func wrapDMLLogical(e *env.Env, bagType int, tokens []FmtToken) []FmtToken {
 			}
 
 			lCnt = 0
-			idxStart = idx
+			idxStart = idx + 1
 		}
 		if tokens[idx].IsKeyword() {
 			pKwVal = tokens[idx].AsUpper()
@@ -834,6 +854,158 @@ func wrapDMLLogical(e *env.Env, bagType int, tokens []FmtToken) []FmtToken {
 	return tokens
 }
 
+// wrapDDLClauses breaks over-long DDL and DCL lines before the clauses
+// (USING, WHERE, ON, TO, etc.) that follow the object being acted upon, before
+// each of the actions of an ALTER, for CREATE TABLE before each of the column
+// and constraint definitions, and before the expression of a generated column
+func wrapDDLClauses(e *env.Env, bagType int, tokens []FmtToken) []FmtToken {
+
+	if len(tokens) == 0 {
+		return tokens
+	}
+
+	idxMax := len(tokens) - 1
+	idxLineStart := 0
+	idxDefStart := 0 // the start of the current column definition (or line)
+	indents := 0
+	lpd := 0
+	parensDepth := 0
+	addBreaks := false
+	hasPrivBreaks := false
+
+	isTable := bagType == DDLBag && tokens[0].AsUpper() == "CREATE" && ddlObjType(e, tokens) == "TABLE"
+	idxColsOpen := -1 // the opening parens of the column definitions
+
+	isAlter := bagType == DDLBag && tokens[0].AsUpper() == "ALTER"
+	idxObjName := -1 // the name of the object being altered
+
+	idxGenExpr := -1 // the start of the expression of a generated column
+
+	for idx := 0; idx <= idxMax; idx++ {
+
+		if idx == 0 || (tokens[idx].vSpace > 0 && idx != idxGenExpr) {
+			idxLineStart = idx
+			idxDefStart = idx
+			indents = tokens[idx].indents + 1
+			lpd = parensDepth
+			addBreaks = calcLenToLineEnd(e, bagType, tokens[idx:]) > e.MaxLineLength()
+			hasPrivBreaks = false
+		}
+
+		if isTable && idxColsOpen < 0 && idx > 0 && tokens[idx].value == "(" && tokens[idx-1].IsIdentifier() {
+			idxColsOpen = idx
+		}
+
+		if isAlter && idxObjName < 0 && tokens[idx].IsIdentifier() {
+			idxObjName = idx
+		}
+
+		if addBreaks && idxColsOpen >= 0 && idx > idxColsOpen && parensDepth == lpd+1 {
+			switch {
+			case idx == idxColsOpen+1, tokens[idx-1].value == ",":
+				tokens[idx].EnsureVSpace()
+				tokens[idx].AdjustIndents(indents + 1)
+				tokens[idx].fbp = true
+				idxDefStart = idx
+			}
+		}
+
+		if addBreaks && idx > idxLineStart && parensDepth == lpd && tokens[idx].IsKeyword() {
+			isClause := false
+			switch bagType {
+			case DDLBag:
+				switch tokens[idx].AsUpper() {
+				case "USING", "WHERE", "WITH":
+					isClause = true
+				case "ADD", "ALTER", "DROP", "RENAME":
+					// the actions of an ALTER follow either the name of the
+					// object or the comma that ends the previous action
+					isClause = isAlter && (idx == idxObjName+1 || tokens[idx-1].value == ",")
+				}
+			case DCLBag:
+				switch tokens[idx].AsUpper() {
+				case "ON", "TO", "FROM", "WITH":
+					isClause = true
+				}
+			}
+			if isClause {
+				tokens[idx].EnsureVSpace()
+				tokens[idx].AdjustIndents(indents)
+				idxDefStart = idx
+
+				// wrap any remaining over-long list of privileges
+				if !hasPrivBreaks {
+					switch tokens[idxLineStart].AsUpper() {
+					case "GRANT", "REVOKE":
+						addCsvBreaks(e, bagType, indents+1, idxLineStart, idxLineStart, idx, wrapHorizontal, &tokens)
+					}
+					hasPrivBreaks = true
+				}
+			}
+		}
+
+		// the generated column clause starts a new line when the column
+		// definition is too long and, should it still be too long, so does
+		// the expression
+		if bagType == DDLBag && idx > idxDefStart && tokens[idx].AsUpper() == "GENERATED" {
+			idxDefEnd := ddlDefinitionEnd(tokens, idx)
+			if calcSliceLen(e, bagType, tokens[idxDefStart:idxDefEnd]) > e.MaxLineLength() {
+				genIndents := tokens[idxDefStart].indents + 1
+				tokens[idx].EnsureVSpace()
+				tokens[idx].AdjustIndents(genIndents)
+				tokens[idx].fbp = true
+
+				if calcSliceLen(e, bagType, tokens[idx:idxDefEnd]) > e.MaxLineLength() {
+					for i := idx + 1; i < idxDefEnd-1; i++ {
+						if tokens[i].value == "(" && tokens[i-1].AsUpper() == "AS" {
+							tokens[i+1].EnsureVSpace()
+							tokens[i+1].AdjustIndents(genIndents + 1)
+							tokens[i+1].fbp = true
+							idxGenExpr = i + 1
+							break
+						}
+					}
+				}
+			}
+		}
+
+		switch tokens[idx].value {
+		case "(":
+			parensDepth++
+		case ")":
+			parensDepth--
+		}
+	}
+	return tokens
+}
+
+// ddlDefinitionEnd returns the index of the token that ends the line of the
+// column (or constraint) definition that contains the specified token: the
+// comma that separates it from the next definition, the closing parens of the
+// list of definitions, or the start of the next line
+func ddlDefinitionEnd(tokens []FmtToken, idxStart int) int {
+
+	parensDepth := 0
+	for idx := idxStart; idx < len(tokens); idx++ {
+		switch {
+		case idx > idxStart && tokens[idx].vSpace > 0:
+			return idx
+		case tokens[idx].value == "(":
+			parensDepth++
+		case tokens[idx].value == ")":
+			if parensDepth == 0 {
+				return idx
+			}
+			parensDepth--
+		case tokens[idx].value == ",", tokens[idx].value == ";":
+			if parensDepth == 0 {
+				return idx + 1
+			}
+		}
+	}
+	return len(tokens)
+}
+
 func wrapDMLWindowFunctions(e *env.Env, bagType, mxPd int, tokens []FmtToken) []FmtToken {
 
 	if len(tokens) == 0 {
@@ -5,6 +5,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/gsiems/db-dialect/dialect"
 )
@@ -137,7 +139,7 @@ func (p *Parser) tokenizeStatement(stmts string) ([]Token, error) {
 			if psIdx >= 0 && (dsIdx < 0 || psIdx < dsIdx) {
 				// there was a psql backslash command found first
 
-				ts, err := p.tokenizeChunk(remainder[:psIdx])
+				ts, err := p.tokenizePsqlChunk(remainder[:psIdx])
 				if err != nil {
 					return tlRe, err
 				}
@@ -176,7 +178,7 @@ func (p *Parser) tokenizeStatement(stmts string) ([]Token, error) {
 			} else if dsIdx >= 0 && deIdx > dsIdx {
 				// there was a copy command found first
 
-				ts, err := p.tokenizeChunk(remainder[:dsIdx])
+				ts, err := p.tokenizePsqlChunk(remainder[:dsIdx])
 				if err != nil {
 					return tlRe, err
 				}
@@ -195,7 +197,7 @@ func (p *Parser) tokenizeStatement(stmts string) ([]Token, error) {
 			} else {
 
 				if len(remainder) > 0 {
-					ts, err := p.tokenizeChunk(remainder)
+					ts, err := p.tokenizePsqlChunk(remainder)
 					if err != nil {
 						return tlRe, err
 					}
@@ -205,6 +207,72 @@ func (p *Parser) tokenizeStatement(stmts string) ([]Token, error) {
 			}
 		}
 
+	case dialect.Oracle:
+
+		// If the input is for Oracle then find any SQL*Plus commands and
+		// store them as single tokens as they aren't SQL and would probably
+		// get corrupted by any further parsing. Commands are only recognized
+		// when they start at the beginning of a line that isn't within a
+		// comment or quoted string.
+		spCmd := regexp.MustCompile(`(?im)^(` + sqlPlusCmds + `)[^\r\n]*`)
+		tlRe, err = p.tokenizeCommands(stmts, spCmd)
+
+	case dialect.MSSQL:
+
+		// If the input is for MS SQL-Server then find any GO batch
+		// separators and sqlcmd commands and store them as single tokens as
+		// they are client commands and not T-SQL. As with SQL*Plus, commands
+		// are only recognized when they start at the beginning of a line that
+		// isn't within a comment or quoted string.
+		sqCmd := regexp.MustCompile(`(?im)^(GO([ \t]+\d+)?[ \t]*$|:(SETVAR|R|ON[ \t]+ERROR|CONNECT|OUT|ERROR)\b[^\r\n]*)`)
+		tlRe, err = p.tokenizeCommands(stmts, sqCmd)
+
+	case dialect.MySQL, dialect.MariaDB:
+
+		// If the input is for MySQL/MariaDB then find any DELIMITER commands
+		// and store them as single tokens as they are client commands and not
+		// SQL. Until the next DELIMITER command, the chosen delimiter is used
+		// to terminate statements rather than the semi-colon. As with
+		// SQL*Plus, DELIMITER commands within comments and quoted strings
+		// are ignored.
+		dCmd := regexp.MustCompile(`(?im)^[ \t]*DELIMITER[ \t]+(\S+)[^\r\n]*`)
+
+		delim := ";"
+		iStart := 0
+		for _, dm := range p.commandIndexes(stmts, dCmd) {
+
+			// The white-space preceding the command belongs to the command
+			pre := strings.TrimRight(stmts[iStart:dm[0]], " \t\r\n")
+
+			ts, err := p.tokenizeDelimited(pre, delim)
+			if err != nil {
+				return tlRe, err
+			}
+			tlRe = append(tlRe, ts...)
+
+			cmd := strings.TrimSpace(stmts[dm[0]:dm[1]])
+			cStart := strings.Index(stmts[dm[0]:dm[1]], cmd) + dm[0]
+
+			nt, err := NewToken(cmd, Data)
+			if err != nil {
+				return tlRe, err
+			}
+			nt.SetLeadingSpace(stmts[iStart+len(pre) : cStart])
+
+			tlRe = append(tlRe, nt)
+
+			delim = stmts[dm[2]:dm[3]]
+			iStart = cStart + len(cmd)
+		}
+
+		if iStart < len(stmts) {
+			ts, err := p.tokenizeDelimited(stmts[iStart:], delim)
+			if err != nil {
+				return tlRe, err
+			}
+			tlRe = append(tlRe, ts...)
+		}
+
 	default:
 		tlRe, err = p.tokenizeChunk(stmts)
 	}
@@ -212,9 +280,249 @@ func (p *Parser) tokenizeStatement(stmts string) ([]Token, error) {
 	return tlRe, err
 }
 
+// tokenizePsqlChunk tokenizes a chunk of PostgreSQL input that does not
+// contain any psql backslash commands at the start of a line. Any backslash
+// commands that follow SQL on the same line (such as the "\g" family of
+// commands that take the place of the statement terminator) extend to the end
+// of the line and are tokenized as single Data tokens.
+func (p *Parser) tokenizePsqlChunk(stmts string) ([]Token, error) {
+
+	tl, err := p.tokenizeChunk(stmts)
+	if err != nil {
+		return tl, err
+	}
+
+	var tlRe []Token
+
+	idxMax := len(tl) - 1
+	for idx := 0; idx <= idxMax; idx++ {
+
+		tc := tl[idx]
+
+		switch tc.categoryOf {
+		case Comment, String, Identifier:
+			tlRe = append(tlRe, tc)
+			continue
+		}
+
+		if !strings.HasPrefix(tc.Value(), "\\") {
+			tlRe = append(tlRe, tc)
+			continue
+		}
+
+		for idx < idxMax && tl[idx+1].vSpace == 0 {
+			idx++
+			tc.WriteString(tl[idx].hSpace)
+			tc.WriteString(tl[idx].Value())
+		}
+		tc.SetType(Data)
+		tlRe = append(tlRe, tc)
+	}
+
+	return tlRe, nil
+}
+
+// tokenizeCommands tokenizes a chunk of input that may contain client
+// commands (as matched by the supplied regular expression). Each command is
+// tokenized as a single Data token.
+func (p *Parser) tokenizeCommands(stmts string, cmdRe *regexp.Regexp) ([]Token, error) {
+
+	var tlRe []Token
+
+	iStart := 0
+	for _, sp := range p.commandIndexes(stmts, cmdRe) {
+
+		// The white-space preceding the command belongs to the command
+		pre := strings.TrimRight(stmts[iStart:sp[0]], " \t\r\n")
+
+		ts, err := p.tokenizeChunk(pre)
+		if err != nil {
+			return tlRe, err
+		}
+		tlRe = append(tlRe, ts...)
+
+		nt, err := NewToken(string(stmts[sp[0]:sp[1]]), Data)
+		if err != nil {
+			return tlRe, err
+		}
+		nt.SetLeadingSpace(string(stmts[iStart+len(pre) : sp[0]]))
+
+		tlRe = append(tlRe, nt)
+
+		iStart = sp[1]
+	}
+
+	if iStart < len(stmts) {
+		ts, err := p.tokenizeChunk(stmts[iStart:])
+		if err != nil {
+			return tlRe, err
+		}
+		tlRe = append(tlRe, ts...)
+	}
+
+	return tlRe, nil
+}
+
+// commandIndexes returns the submatch indexes of the client commands (as
+// matched by the supplied regular expression) that start at the beginning of
+// a line. As the commands are matched against the raw input, any matches that
+// start within a comment, quoted string, or quoted identifier are dropped.
+// The lexical state is tracked in the same manner as the StatementReader
+// does, with the lines of the commands themselves not being scanned.
+func (p *Parser) commandIndexes(stmts string, cmdRe *regexp.Regexp) [][]int {
+
+	matches := cmdRe.FindAllStringSubmatchIndex(stmts, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	var ret [][]int
+
+	s := StatementReader{p: p, delim: ";"}
+
+	lineStart := 0
+	for lineStart < len(stmts) && len(matches) > 0 {
+
+		lineEnd := len(stmts)
+		if i := strings.IndexByte(stmts[lineStart:], '\n'); i >= 0 {
+			lineEnd = lineStart + i + 1
+		}
+
+		for len(matches) > 0 && matches[0][0] < lineStart {
+			matches = matches[1:]
+		}
+
+		if len(matches) > 0 && matches[0][0] == lineStart && s.closer == "" && s.cmtDepth == 0 {
+			cmdEnd := matches[0][1]
+			ret = append(ret, matches[0])
+			matches = matches[1:]
+
+			// Resume scanning at the start of the line following the command
+			lineStart = len(stmts)
+			if i := strings.IndexByte(stmts[cmdEnd:], '\n'); i >= 0 {
+				lineStart = cmdEnd + i + 1
+			}
+			continue
+		}
+
+		s.scanLine(stmts[lineStart:lineEnd])
+		lineStart = lineEnd
+	}
+
+	return ret
+}
+
+// tokenizeDelimited tokenizes a chunk of MySQL/MariaDB input for which a
+// DELIMITER command has set a statement delimiter other than the semi-colon.
+// Each occurrence of the delimiter is tokenized as a single SemiColon token.
+func (p *Parser) tokenizeDelimited(stmts, delim string) ([]Token, error) {
+
+	if delim == ";" {
+		return p.tokenizeChunk(stmts)
+	}
+
+	var tlRe []Token
+
+	remainder := stmts
+	for len(remainder) > 0 {
+
+		idx := p.delimiterIndex(remainder, delim)
+		if idx < 0 {
+			ts, err := p.tokenizeChunk(remainder)
+			if err != nil {
+				return tlRe, err
+			}
+			tlRe = append(tlRe, ts...)
+			break
+		}
+
+		// The white-space preceding the delimiter belongs to the delimiter
+		pre := strings.TrimRight(remainder[:idx], " \t\r\n")
+
+		ts, err := p.tokenizeChunk(pre)
+		if err != nil {
+			return tlRe, err
+		}
+		tlRe = append(tlRe, ts...)
+
+		nt, err := NewToken(delim, SemiColon)
+		if err != nil {
+			return tlRe, err
+		}
+		nt.SetLeadingSpace(remainder[len(pre):idx])
+
+		tlRe = append(tlRe, nt)
+
+		remainder = remainder[idx+len(delim):]
+	}
+
+	return tlRe, nil
+}
+
+// delimiterIndex returns the index of the first occurrence of the delimiter
+// that is not in a quoted string, quoted identifier, or comment. Returns -1 if
+// there is no such occurrence.
+func (p *Parser) delimiterIndex(s, delim string) int {
+
+	var closer string
+	idxMax := len(s) - 1
+
+	for idx := 0; idx <= idxMax; idx++ {
+
+		switch closer {
+		case "":
+			// nada
+		case "\n", "*/":
+			if strings.HasPrefix(s[idx:], closer) {
+				idx += len(closer) - 1
+				closer = ""
+			}
+			continue
+		default:
+			switch {
+			case s[idx] == '\\' && closer != "`":
+				idx++
+			case strings.HasPrefix(s[idx:], closer):
+				closer = ""
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(s[idx:], delim):
+			return idx
+		case s[idx] == '\'', s[idx] == '"', s[idx] == '`':
+			closer = string(s[idx])
+		case s[idx] == '#', strings.HasPrefix(s[idx:], "-- "):
+			closer = "\n"
+		case strings.HasPrefix(s[idx:], "/*"):
+			closer = "*/"
+			idx++
+		}
+	}
+
+	return -1
+}
+
+// sqlPlusCmds is the regular expression for matching the beginning of those
+// SQL*Plus commands that are likely to be found in Oracle scripts. For SET,
+// only the SQL*Plus system variables are matched, and then only when not
+// followed by an "=", so as to not match the SET clause of an UPDATE statement
+// (such as "SET time = sysdate"). Likewise, CONNECT needs a connect string (so
+// as to not match CONNECT BY) and EXIT can't be followed by WHEN.
+const sqlPlusCmds = `SET\s+(APPI(NFO)?|ARRAY(SIZE)?|AUTO(COMMIT)?|AUTOT(RACE)?|COLSEP|CON(CAT)?|DEF(INE)?|ECHO|ESC(APE)?|FEED(BACK)?|HEA(DING)?|LIN(ESIZE)?|LONG|MARKUP|NEWP(AGE)?|NULL|NUMW(IDTH)?|PAGES(IZE)?|PAU(SE)?|SCAN|SERVEROUT(PUT)?|SQLBL(ANKLINES)?|SQLP(ROMPT)?|TAB|TERM(OUT)?|TI(ME)?|TIMI(NG)?|TRIMS(POOL)?|TRIM(OUT)?|VER(IFY)?|WRA(P)?)([ \t]+[^=\s]|[ \t]*$)` +
+	`|PRO(MPT)?\b|REM(ARK)?\b|SPO(OL)?\b|WHENEVER\s+(SQLERROR|OSERROR)\b|DEF(INE)?\b|UNDEF(INE)?\b` +
+	`|@@?|STA(RT)?\s+\S+\.sql\b|CONN(ECT)?\s+\S*[/@]|DISC(ONNECT)?\b|HO(ST)?\b|PAUSE\b|SHO(W)?\b` +
+	`|(EXIT|QUIT)([ \t]+(SUCCESS|FAILURE|WARNING|SQL\.SQLCODE|\d+))?([ \t]+(COMMIT|ROLLBACK))?[ \t]*;?[ \t]*$` +
+	`|COLUMN\b|VARIABLE\b|PRINT\b|ACC(EPT)?\b|CL(EAR)?\s+(SCR(EEN)?|COL(UMNS)?|BREAKS?|BUFF(ER)?|COMP(UTES)?)\b` +
+	`|TTI(TLE)?\b|BTI(TLE)?\b|EXEC\b`
+
 // tokenizeChunk is primarily about resolving those tokens that are either
 // delimited by standard start/end character strings (like comments and
 // comment blocks), are white-space, or are stand-alone punctuation.
+//
+// The input is walked one character (rune) at a time so that multi-byte
+// UTF-8 characters are never split across tokens.
 func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 
 	var tlRe []Token
@@ -224,23 +532,33 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 	}
 
 	qiMax := len(stmts) - 1
-	qi := -1
+	qi := 0
+	chrLen := 0 // the length, in bytes, of the current character
 	iStart := 0
 	tType := NullItem
+	escapable := false // backslash escapes are valid for the current string
+	cmtDepth := 0      // the nesting depth of the current block comment
 
-	for qi < qiMax {
-		qi++
+	for qi+chrLen <= qiMax {
+		qi += chrLen
+		chrLen = runeLen(stmts, qi)
 
-		chr := string(stmts[qi])
+		chr := stmts[qi : qi+chrLen]
 		chrNext := ""
-		if qi < qiMax {
-			chrNext = string(stmts[qi+1])
+		if qi+chrLen <= qiMax {
+			iNext := qi + chrLen
+			chrNext = stmts[iNext : iNext+runeLen(stmts, iNext)]
 		}
 
 		// Dealing with an escape char?
-		if chr == "\\" {
-			qi++
-			continue
+		if chr == "\\" && escapable {
+			switch tType {
+			case DoubleQuoted, SingleQuoted:
+				// skip the escaped character
+				qi += chrLen
+				chrLen = runeLen(stmts, qi)
+				continue
+			}
 		}
 
 		// if we are in an *enclosed* token (has a defined start and end
@@ -249,7 +567,7 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 		case DoubleQuoted, SingleQuoted, BacktickQuoted, BracketQuoted:
 
 			if p.chkTokenEnd(chr, tType) {
-				if qi+1 > iStart && qi+1 <= qiMax {
+				if qi+1 > iStart && qi <= qiMax {
 					nt, err := NewToken(string(stmts[iStart:qi+1]), tType)
 					if err != nil {
 						return tlRe, err
@@ -267,8 +585,18 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 			continue
 
 		case BlockComment:
+			if chr+chrNext == "/*" && p.hasNestedComments() {
+				cmtDepth++
+				qi++
+				continue
+			}
 			if p.chkTokenEnd(chr+chrNext, tType) {
-				if qi+2 > iStart && qi+2 <= qiMax {
+				cmtDepth--
+				if cmtDepth > 0 {
+					qi++
+					continue
+				}
+				if qi+2 > iStart && qi+1 <= qiMax {
 					nt, err := NewToken(string(stmts[iStart:qi+2]), tType)
 					if err != nil {
 						return tlRe, err
@@ -299,6 +627,38 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 			continue
 		}
 
+		// check for the beginning of a string that is terminated by a tag or
+		// a user-specified delimiter rather than by a single quote character
+		// (dollar-quoting and Oracle alternative quoting). As the end of
+		// the string can't be determined one character at a time, the
+		// entire string is extracted here.
+		if iEnd, tt := p.chkDelimitedString(stmts, qi); iEnd > qi {
+
+			if qi > iStart {
+				nt, err := NewToken(string(stmts[iStart:qi]), tType)
+				if err != nil {
+					return tlRe, err
+				}
+				tlRe = append(tlRe, nt)
+			}
+
+			nt, err := NewToken(string(stmts[qi:iEnd]), tt)
+			if err != nil {
+				return tlRe, err
+			}
+			tlRe = append(tlRe, nt)
+
+			iStart = iEnd
+			qi = iEnd - 1
+
+			if iStart <= qiMax && p.isWhiteSpaceChar(string(stmts[iStart])) {
+				tType = WhiteSpace
+			} else {
+				tType = Other
+			}
+			continue
+		}
+
 		// check for the beginning of an *enclosed* token
 		tt2 := p.chkTokenStart(chr, chrNext)
 		switch tt2 {
@@ -316,6 +676,11 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 				iStart = qi
 			}
 			tType = tt2
+			escapable = p.isEscapable(tt2, stmts, qi)
+			if tt2 == BlockComment {
+				cmtDepth = 1
+				qi++
+			}
 			continue
 		}
 
@@ -391,7 +756,7 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 		// Don't know (yet) what to do with it
 		if tType != Other {
 			if qi > iStart && iStart <= qiMax {
-				nt, err := NewToken(string(stmts[iStart]), tType)
+				nt, err := NewToken(stmts[iStart:qi], tType)
 				if err != nil {
 					return tlRe, err
 				}
@@ -403,8 +768,8 @@ func (p *Parser) tokenizeChunk(stmts string) ([]Token, error) {
 	}
 
 	// Catch the final bits. Whether simply whites-space or some un-closed
-	// delimited thing
-	if qi > iStart && iStart <= qiMax {
+	// delimited thing (or a final single character token such as a "/")
+	if iStart <= qiMax && (qi > iStart || tType != WhiteSpace) {
 		nt, err := NewToken(string(stmts[iStart:]), tType)
 		if err != nil {
 			return tlRe, err
@@ -448,7 +813,7 @@ func (p *Parser) updateTokenTypes(tlIn []Token) []Token {
 				default:
 					tc.categoryOf = Identifier
 				}
-			case SingleQuoted:
+			case SingleQuoted, DollarQuoted:
 				tc.categoryOf = String
 			case BacktickQuoted, BracketQuoted:
 				tc.categoryOf = Identifier
@@ -503,6 +868,7 @@ func (p *Parser) updateTokenTypes(tlIn []Token) []Token {
 //
 //   - N'string literal',
 //   - E'string literal',
+//   - q'[string literal]', nq'{string literal}' (Oracle alternative quoting),
 //   - _utf8'string literal',
 //   - etc.
 //
@@ -539,8 +905,31 @@ func (p *Parser) consolidateStrings(tlIn []Token) []Token {
 			break
 		}
 
+		// psql variable interpolation, :'var' and :"var"
+		if p.isPsqlVariable(tlIn[idx:]) {
+			tc.WriteString(tlIn[idx+1].Value())
+			tc.SetType(BindParameter)
+			tlRe = append(tlRe, tc)
+			idx++
+			continue
+		}
+
+		// Unicode escaped strings and identifiers, U&'...' and U&"...",
+		// along with any UESCAPE clause
+		if n := p.unicodeEscapeLen(tlIn[idx:]); n > 0 {
+			tc = p.joinUnicodeEscape(tlIn[idx : idx+n])
+			tlRe = append(tlRe, tc)
+			idx += n - 1
+			continue
+		}
+
 		if tc.categoryOf == String || tc.typeOf == Identifier {
 
+			prefix := ""
+			if tc.typeOf == Identifier {
+				prefix = tc.Value()
+			}
+
 			// As long as the next token is a string literal
 			// with no leading white-space then we want to join them
 			doContinue := true
@@ -553,8 +942,9 @@ func (p *Parser) consolidateStrings(tlIn []Token) []Token {
 
 					tc.WriteString(tcNext.Value())
 
-					tc.typeOf = tcNext.typeOf
+					tc.typeOf = p.prefixedStringType(prefix, tcNext.typeOf)
 					tc.categoryOf = tcNext.categoryOf
+					prefix = ""
 					idx++
 					continue
 				}
@@ -571,6 +961,105 @@ func (p *Parser) consolidateStrings(tlIn []Token) []Token {
 	return p.consolidateWhitespace(tlRe)
 }
 
+// prefixedStringType determines the type of a single quoted string literal
+// based on the prefix (if any) that was joined to it
+func (p *Parser) prefixedStringType(prefix string, typeOf int) int {
+
+	if typeOf != SingleQuoted {
+		return typeOf
+	}
+
+	switch strings.ToUpper(prefix) {
+	case "B":
+		return BitString
+	case "X":
+		return HexString
+	case "E":
+		if p.dbdialect.Dialect() == dialect.PostgreSQL {
+			return EscapeString
+		}
+	}
+	return typeOf
+}
+
+// isPsqlVariable determines whether or not the supplied tokens start with a
+// psql variable that is interpolated as a literal (:'var') or as an
+// identifier (:"var")
+func (p *Parser) isPsqlVariable(tl []Token) bool {
+
+	if p.dbdialect.Dialect() != dialect.PostgreSQL {
+		return false
+	}
+
+	if len(tl) < 2 || tl[0].Value() != ":" {
+		return false
+	}
+
+	switch tl[1].typeOf {
+	case SingleQuoted, DoubleQuoted:
+		return true
+	}
+	return false
+}
+
+// unicodeEscapeLen checks the supplied tokens for a Unicode escaped string
+// or identifier (U&'...' or U&"...") optionally followed by a UESCAPE clause
+// and returns the number of tokens that comprise it (zero if not found)
+func (p *Parser) unicodeEscapeLen(tl []Token) int {
+
+	switch p.dbdialect.Dialect() {
+	case dialect.PostgreSQL, dialect.StandardSQL:
+	default:
+		return 0
+	}
+
+	if len(tl) < 3 || strings.ToUpper(tl[0].Value()) != "U" || tl[1].Value() != "&" {
+		return 0
+	}
+
+	switch tl[2].typeOf {
+	case SingleQuoted, DoubleQuoted:
+	default:
+		return 0
+	}
+
+	// [white-space] UESCAPE [white-space] 'c'
+	n := 3
+	if n < len(tl) && tl[n].typeOf == WhiteSpace {
+		n++
+	}
+	if n >= len(tl) || strings.ToUpper(tl[n].Value()) != "UESCAPE" {
+		return 3
+	}
+	n++
+	if n < len(tl) && tl[n].typeOf == WhiteSpace {
+		n++
+	}
+	if n >= len(tl) || tl[n].typeOf != SingleQuoted {
+		return 3
+	}
+	return n + 1
+}
+
+// joinUnicodeEscape combines the tokens of a Unicode escaped string or
+// identifier into a single token
+func (p *Parser) joinUnicodeEscape(tl []Token) Token {
+
+	tc := tl[0]
+
+	switch tl[2].typeOf {
+	case DoubleQuoted:
+		tc.SetType(UnicodeIdentifier)
+	default:
+		tc.SetType(UnicodeString)
+	}
+
+	for _, t := range tl[1:] {
+		tc.WriteString(t.Value())
+	}
+	return tc
+}
+
 // consolidateWhitespace consolidates white-space tokens by folding them into
 // the following token (if any).
 func (p *Parser) consolidateWhitespace(tlIn []Token) []Token {
@@ -688,6 +1177,215 @@ func (p *Parser) chkTokenStart(s, s2 string) (d int) {
 	return NullItem
 }
 
+// chkDelimitedString checks for the start of a dollar-quoted string or an
+// Oracle alternative quoted string (q'[...]', nq'{...}', etc.) at the
+// specified position of the string and, if found, returns the position of
+// the end of the string and the token type. For Oracle, the q (or nq) prefix
+// precedes the quote and is joined to the string in consolidateStrings.
+func (p *Parser) chkDelimitedString(s string, idx int) (int, int) {
+
+	switch s[idx] {
+	case '$':
+		tag := p.dollarQuoteTag(s, idx)
+		if tag == "" {
+			return -1, NullItem
+		}
+		if i := strings.Index(s[idx+len(tag):], tag); i >= 0 {
+			return idx + len(tag) + i + len(tag), DollarQuoted
+		}
+		return len(s), DollarQuoted
+
+	case '\'':
+		if !p.isAltQuoteStart(s, idx) {
+			return -1, NullItem
+		}
+
+		var closers = map[byte]byte{'[': ']', '{': '}', '(': ')', '<': '>'}
+
+		delim := s[idx+1]
+		if c, ok := closers[delim]; ok {
+			delim = c
+		}
+
+		if i := strings.Index(s[idx+2:], string(delim)+"'"); i >= 0 {
+			return idx + 2 + i + 2, SingleQuoted
+		}
+		return len(s), SingleQuoted
+	}
+
+	return -1, NullItem
+}
+
+// isAltQuoteStart determines whether or not the single quote at the
+// specified position of the string is the start of an Oracle alternative
+// quoted string (q'[...]', Q'{...}', nq'<...>', etc.)
+func (p *Parser) isAltQuoteStart(s string, idx int) bool {
+
+	if p.dbdialect.Dialect() != dialect.Oracle {
+		return false
+	}
+
+	if idx < 1 || idx+1 >= len(s) {
+		return false
+	}
+
+	switch s[idx-1] {
+	case 'q', 'Q':
+	default:
+		return false
+	}
+
+	// The delimiter can be any character other than white-space
+	if p.isWhiteSpaceChar(string(s[idx+1])) {
+		return false
+	}
+
+	// The "q" needs to be either the entire prefix or follow an "n"
+	switch {
+	case idx < 2:
+		return true
+	case s[idx-2] == 'n', s[idx-2] == 'N':
+		return idx < 3 || !isIdentChar(s[idx-3], true)
+	}
+	return !isIdentChar(s[idx-2], true)
+}
+
+// dollarQuoteTag returns the dollar quote tag ("$$", "$tag$") that starts at
+// the specified position of the string, if any. Dollar quoting is only
+// supported for PostgreSQL.
+func (p *Parser) dollarQuoteTag(s string, idx int) string {
+
+	if p.dbdialect.Dialect() != dialect.PostgreSQL {
+		return ""
+	}
+
+	// A "$" that is part of an identifier ("foo$bar") or that follows a
+	// bind parameter ("$1$") doesn't start a tag
+	if idx > 0 && isIdentChar(s[idx-1], true) {
+		return ""
+	}
+
+	for i := idx + 1; i < len(s); i++ {
+		switch {
+		case s[i] == '$':
+			return s[idx : i+1]
+		case !isIdentChar(s[i], i > idx+1):
+			return ""
+		}
+	}
+	return ""
+}
+
+// isIdentChar determines whether or not the supplied character is valid for
+// an un-quoted identifier or a dollar quote tag (digits and dollar signs are
+// only valid after the first character)
+func isIdentChar(c byte, allowDigits bool) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c >= 0x80:
+		return true
+	case c >= '0' && c <= '9', c == '$':
+		return allowDigits
+	}
+	return false
+}
+
+// SplitDollarQuoted splits a dollar-quoted string token into the opening
+// tag, the tokens for the contents of the string, and the closing tag. This
+// is for those dollar-quoted strings that contain code (such as function
+// bodies) that are to be formatted rather than treated as string literals.
+func (p *Parser) SplitDollarQuoted(t Token) ([]Token, error) {
+
+	value := t.Value()
+
+	if t.typeOf != DollarQuoted {
+		return []Token{t}, nil
+	}
+
+	tag := value[:strings.Index(value[1:], "$")+2]
+	if len(value) < len(tag)*2 || !strings.HasSuffix(value, tag) {
+		// Un-terminated string
+		return []Token{t}, nil
+	}
+
+	inner := value[len(tag) : len(value)-len(tag)]
+	body := strings.TrimRight(inner, " \t\r\n")
+
+	var tlRe []Token
+
+	ot, err := NewToken(tag, Other)
+	if err != nil {
+		return tlRe, err
+	}
+	ot.vSpace = t.vSpace
+	ot.hSpace = t.hSpace
+	tlRe = append(tlRe, ot)
+
+	bt, err := p.tokenizeChunk(body)
+	if err != nil {
+		return tlRe, err
+	}
+	tlRe = append(tlRe, bt...)
+
+	ct, err := NewToken(tag, Other)
+	if err != nil {
+		return tlRe, err
+	}
+	ct.SetLeadingSpace(inner[len(body):])
+	tlRe = append(tlRe, ct)
+
+	return tlRe, nil
+}
+
+// hasNestedComments determines whether or not block comments can be nested
+// for the dialect being parsed
+func (p *Parser) hasNestedComments() bool {
+	switch p.dbdialect.Dialect() {
+	case dialect.PostgreSQL, dialect.MSSQL:
+		return true
+	}
+	return false
+}
+
+// isEscapable determines whether or not backslash escapes are valid for the
+// quoted string that starts at the specified position of the string.
+//
+//   - MySQL and MariaDB: single and double quoted strings
+//   - PostgreSQL: only for E'...' strings (standard conforming strings)
+//   - Others: never
+func (p *Parser) isEscapable(tokenType int, s string, idx int) bool {
+
+	switch tokenType {
+	case SingleQuoted, DoubleQuoted:
+	default:
+		return false
+	}
+
+	switch p.dbdialect.Dialect() {
+	case dialect.MySQL, dialect.MariaDB:
+		return true
+	case dialect.PostgreSQL:
+		if tokenType != SingleQuoted || idx < 1 {
+			return false
+		}
+		switch s[idx-1] {
+		case 'E', 'e':
+			// The "E" needs to be a prefix and not the end of an identifier
+			return idx < 2 || !isIdentChar(s[idx-2], true)
+		}
+	}
+	return false
+}
+
+// runeLen returns the length, in bytes, of the character that starts at the
+// specified position of the string
+func runeLen(s string, idx int) int {
+	if idx >= len(s) {
+		return 1
+	}
+	_, w := utf8.DecodeRuneInString(s[idx:])
+	return w
+}
+
 // chkTokenEnd checks the string provided to determine if it is the end of
 // an *enclosed* token such as a quoted string, line comment, etc.
 func (p *Parser) chkTokenEnd(s string, typeOf int) bool {
@@ -716,6 +1414,9 @@ func (p *Parser) chkTokenEnd(s string, typeOf int) bool {
 func (p *Parser) chkTokenString(s string) (d int) {
 
 	switch true {
+	case p.dbdialect.Dialect() == dialect.MSSQL && isTSQLVariable(s):
+		// T-SQL local variables and parameters
+		return BindParameter
 	case p.dbdialect.IsDatatype(s):
 		return Datatype
 	case p.dbdialect.IsKeyword(s):
@@ -726,6 +1427,8 @@ func (p *Parser) chkTokenString(s string) (d int) {
 		return Numeric
 	case p.dbdialect.IsIdentifier(s):
 		return Identifier
+	case isUnicodeIdentifier(s):
+		return Identifier
 	case p.dbdialect.IsOperator(s):
 		return Operator
 	case p.isBindVar(s):
@@ -735,6 +1438,27 @@ func (p *Parser) chkTokenString(s string) (d int) {
 	return NullItem
 }
 
+// isUnicodeIdentifier determines whether or not the supplied string is an
+// unquoted identifier that contains non-ASCII letters (prénom, 顧客, etc.)
+func isUnicodeIdentifier(s string) bool {
+
+	hasNonASCII := false
+	for i, r := range s {
+		switch {
+		case r >= utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsMark(r)):
+			hasNonASCII = true
+		case unicode.IsLetter(r), r == '_':
+		case unicode.IsDigit(r), r == '$':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return hasNonASCII
+}
+
 // isWhiteSpaceChar determines whether or not the supplied character is
 // considered to be a white space character
 func (p *Parser) isWhiteSpaceChar(s string) bool {
@@ -775,6 +1499,9 @@ func (p *Parser) isBindVar(s string) bool {
 	if s == "?" {
 		return true
 	}
+	if p.dbdialect.Dialect() == dialect.Oracle && p.isSubstitutionVar(s) {
+		return true
+	}
 	if len(s) > 1 {
 		if string(s[0]) == ":" && strings.Count(s, ":") == 1 {
 			return true
@@ -785,3 +1512,44 @@ func (p *Parser) isBindVar(s string) bool {
 	}
 	return false
 }
+
+// isTSQLVariable determines whether or not the supplied string is a T-SQL
+// variable (@x) or system function (@@x)
+func isTSQLVariable(s string) bool {
+
+	v := strings.TrimPrefix(s, "@")
+	v = strings.TrimPrefix(v, "@")
+
+	if v == "" || v == s {
+		return false
+	}
+
+	for idx := 0; idx < len(v); idx++ {
+		if !isIdentChar(v[idx], true) && v[idx] != '#' && v[idx] != '@' {
+			return false
+		}
+	}
+	return true
+}
+
+// isSubstitutionVar determines whether or not the supplied string is a
+// SQL*Plus substitution variable (&x or &&x)
+func (p *Parser) isSubstitutionVar(s string) bool {
+
+	v := strings.TrimPrefix(s, "&")
+	v = strings.TrimPrefix(v, "&")
+
+	if v == "" || v == s {
+		return false
+	}
+
+	for _, r := range v {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '_', r == '$', r == '#', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -90,6 +91,25 @@ func TestSQLFiles(t *testing.T) {
 				}
 			}
 
+			////////////////////////////////////////////////////////////////////////
+			// Streaming the input should split it without losing anything
+			var chunks []string
+			sr := NewStatementReader(d, strings.NewReader(input))
+			for {
+				chunk, err := sr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Errorf("Error streaming input for %s (%s)", file.Name(), err)
+					break
+				}
+				chunks = append(chunks, chunk)
+			}
+			if strings.Join(chunks, "") != input {
+				t.Errorf("Input vs streamed failed for %q", file.Name())
+			}
+
 			err = writeParsed(parsedDir, d, file.Name(), parsed)
 			if err != nil {
 				t.Errorf("Error writing parsed for %s: %s", file.Name(), err)
@@ -0,0 +1,487 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gsiems/db-dialect/dialect"
+)
+
+/*
+
+stream.go provides for reading SQL input one (or more) complete top-level
+statements at a time so that very large inputs don't need to be read into
+memory all at once
+
+*/
+
+// StatementReader reads SQL input from an io.Reader and splits it at
+// top-level statement boundaries. Each chunk returned consists of whole lines
+// of the input: the white-space and comments preceding a statement, the
+// statement itself, and anything that follows the terminator on the same
+// line. Statements that depend on each other for context (such as those
+// within psql \if blocks or MySQL DELIMITER blocks) are returned together.
+// The data of a PostgreSQL "COPY ... FROM stdin" is returned one line at a
+// time (see IsCopyData) so that it need not be buffered.
+type StatementReader struct {
+	p      *Parser
+	reader *bufio.Reader
+	buff   strings.Builder
+	eof    bool
+
+	// The lexical state that carries from one line to the next
+	closer    string // the string that closes the current quoted string (or dollar-quote)
+	escapable bool   // backslash escapes are valid for the current string
+	cmtDepth  int    // the nesting depth of the current block comment
+
+	// The state of the current statement
+	inStmt       bool     // a statement has been started
+	words        []string // the first few words of the statement
+	parensDepth  int      // the nesting depth of parentheses
+	blockDepth   int      // the nesting depth of BEGIN ... END and CASE ... END blocks
+	pendingBegin bool     // a BEGIN was found that may or may not start a block
+	isPL         bool     // the statement is an Oracle PL unit (terminated by "/")
+	isCopyStdin  bool     // the statement is a PostgreSQL "COPY ... FROM stdin"
+
+	// The state of the input
+	copyData  bool   // reading the data portion of a "COPY ... FROM stdin"
+	isData    bool   // the chunk last returned is a line of COPY data
+	psqlDepth int    // the nesting depth of psql \if ... \endif blocks
+	delim     string // the current MySQL/MariaDB statement delimiter
+}
+
+var (
+	mySQLDelimRe = regexp.MustCompile(`(?i)^[ \t]*DELIMITER[ \t]+(\S+)`)
+	tsqlBatchRe  = regexp.MustCompile(`(?i)^[ \t]*GO([ \t]+\d+)?[ \t]*\r?\n?$`)
+	sqlPlusCmdRe = regexp.MustCompile(`(?i)^(` + sqlPlusCmds + `)`)
+)
+
+// NewStatementReader creates a StatementReader for reading the specified
+// SQL dialect from the supplied reader
+func NewStatementReader(dName string, r io.Reader) *StatementReader {
+	var s StatementReader
+
+	s.p = NewParser(dName)
+	s.reader = bufio.NewReader(r)
+	s.delim = ";"
+
+	return &s
+}
+
+// Next returns the next chunk of input that ends at a top-level statement
+// boundary (or at the end of the input). io.EOF is returned once all input
+// has been read.
+func (s *StatementReader) Next() (string, error) {
+
+	s.isData = false
+
+	if s.eof {
+		return "", io.EOF
+	}
+
+	if s.copyData && s.buff.Len() == 0 {
+		// The data lines (up to and including the "\." line) are returned
+		// as they are read
+		line, err := s.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if err == io.EOF {
+			s.eof = true
+			if line == "" {
+				return "", io.EOF
+			}
+		}
+		s.scanLine(line)
+		s.isData = true
+		return line, nil
+	}
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		s.buff.WriteString(line)
+		isBoundary := line != "" && s.scanLine(line)
+
+		if err == io.EOF {
+			s.eof = true
+			if s.buff.Len() == 0 {
+				return "", io.EOF
+			}
+			return s.flush(), nil
+		}
+
+		if isBoundary {
+			return s.flush(), nil
+		}
+	}
+}
+
+// IsCopyData returns true if the chunk last returned by Next is a line of the
+// data portion of a "COPY ... FROM stdin" that should be passed through as is
+func (s *StatementReader) IsCopyData() bool {
+	return s.isData
+}
+
+func (s *StatementReader) flush() string {
+	chunk := s.buff.String()
+	s.buff.Reset()
+	return chunk
+}
+
+func (s *StatementReader) resetStatement() {
+	s.inStmt = false
+	s.words = nil
+	s.parensDepth = 0
+	s.blockDepth = 0
+	s.pendingBegin = false
+	s.isPL = false
+	s.isCopyStdin = false
+}
+
+// canSplit determines whether or not the input may be split at the end of
+// the current line
+func (s *StatementReader) canSplit() bool {
+	return s.closer == "" && s.cmtDepth == 0 && !s.copyData && s.psqlDepth == 0 && s.delim == ";"
+}
+
+// scanLine updates the state of the reader for the supplied line and
+// returns true if the line ends at a top-level statement boundary
+func (s *StatementReader) scanLine(line string) bool {
+
+	d := s.p.dbdialect.Dialect()
+
+	if s.copyData {
+		if strings.TrimSpace(line) == "\\." {
+			s.copyData = false
+			s.resetStatement()
+			return s.canSplit()
+		}
+		return false
+	}
+
+	if s.closer == "" && s.cmtDepth == 0 {
+		if isCmd, isBoundary := s.chkCommandLine(d, line); isCmd {
+			return isBoundary
+		}
+	}
+
+	isTerminated := false // the last significant thing on the line is a terminator
+
+	idxMax := len(line) - 1
+	for idx := 0; idx <= idxMax; idx++ {
+
+		c := line[idx]
+
+		// Within a block comment
+		if s.cmtDepth > 0 {
+			switch {
+			case strings.HasPrefix(line[idx:], "/*") && s.p.hasNestedComments():
+				s.cmtDepth++
+				idx++
+			case strings.HasPrefix(line[idx:], "*/"):
+				s.cmtDepth--
+				idx++
+			}
+			continue
+		}
+
+		// Within a quoted string or identifier
+		if s.closer != "" {
+			switch {
+			case c == '\\' && s.escapable:
+				idx++
+			case strings.HasPrefix(line[idx:], s.closer):
+				idx += len(s.closer) - 1
+				s.closer = ""
+			}
+			continue
+		}
+
+		chr := string(c)
+		chrNext := ""
+		if idx < idxMax {
+			chrNext = string(line[idx+1])
+		}
+
+		switch tt := s.p.chkTokenStart(chr, chrNext); tt {
+		case LineComment, PoundLineComment:
+			return isTerminated && s.canSplit()
+		case BlockComment:
+			s.cmtDepth = 1
+			idx++
+			continue
+		case DoubleQuoted, SingleQuoted, BacktickQuoted, BracketQuoted:
+			if iEnd, ok := s.chkDelimitedStart(line, idx); ok {
+				idx = iEnd
+				continue
+			}
+			var closers = map[int]string{
+				DoubleQuoted:   "\"",
+				SingleQuoted:   "'",
+				BacktickQuoted: "`",
+				BracketQuoted:  "]",
+			}
+			s.closer = closers[tt]
+			s.escapable = s.p.isEscapable(tt, line, idx)
+			s.inStmt = true
+			isTerminated = false
+			continue
+		}
+
+		if c == '$' {
+			if iEnd, ok := s.chkDelimitedStart(line, idx); ok {
+				idx = iEnd
+				continue
+			}
+		}
+
+		switch {
+		case s.p.isWhiteSpaceChar(chr):
+			continue
+
+		case isIdentChar(c, true):
+			iEnd := idx + 1
+			for iEnd <= idxMax && isIdentChar(line[iEnd], true) {
+				iEnd++
+			}
+
+			// Oracle alternative quoting is q'...' so the "q" (or "nq") is
+			// not a word on its own
+			if iEnd <= idxMax && line[iEnd] == '\'' && s.p.isAltQuoteStart(line, iEnd) {
+				idx = iEnd - 1
+				continue
+			}
+
+			s.addWord(d, strings.ToUpper(line[idx:iEnd]))
+			isTerminated = false
+			idx = iEnd - 1
+
+		case c == '\\' && d == dialect.PostgreSQL:
+			// A psql command that follows SQL on the same line runs to the
+			// end of the line. The "\g" family of commands also terminate
+			// the current statement.
+			if isPsqlQueryCmd(line[idx:]) {
+				s.resetStatement()
+				return s.canSplit()
+			}
+			return false
+
+		case chr == ";" && s.delim == ";":
+			s.pendingBegin = false
+			switch {
+			case s.parensDepth > 0, s.blockDepth > 0, s.isPL:
+				// still in the statement
+			case s.isCopyStdin:
+				// the data starts on the next line
+				s.resetStatement()
+				isBoundary := s.canSplit()
+				s.copyData = true
+				return isBoundary
+			default:
+				s.resetStatement()
+				isTerminated = true
+			}
+
+		default:
+			switch chr {
+			case "(":
+				s.parensDepth++
+			case ")":
+				s.parensDepth--
+			}
+			s.inStmt = true
+			isTerminated = false
+		}
+	}
+
+	if d == dialect.MSSQL {
+		// T-SQL statements need not be terminated so only batch
+		// separators are considered to be boundaries
+		return false
+	}
+
+	return isTerminated && s.canSplit()
+}
+
+// chkCommandLine checks for those client commands (psql, SQL*Plus, GO,
+// DELIMITER, etc.) that are recognized at the start of a line and returns
+// whether the line is such a command and whether the line ends at a
+// top-level statement boundary
+func (s *StatementReader) chkCommandLine(d int, line string) (bool, bool) {
+
+	trimmed := strings.TrimSpace(line)
+
+	switch d {
+	case dialect.PostgreSQL:
+		if !strings.HasPrefix(trimmed, "\\") {
+			return false, false
+		}
+
+		switch strings.ToLower(strings.Fields(trimmed)[0]) {
+		case "\\if":
+			s.psqlDepth++
+		case "\\endif":
+			if s.psqlDepth > 0 {
+				s.psqlDepth--
+			}
+		}
+
+		if isPsqlQueryCmd(trimmed) {
+			s.resetStatement()
+		}
+		return true, !s.inStmt && s.canSplit()
+
+	case dialect.Oracle:
+		if trimmed == "/" {
+			s.resetStatement()
+			return true, s.canSplit()
+		}
+		if !s.inStmt && sqlPlusCmdRe.MatchString(trimmed) {
+			return true, s.canSplit()
+		}
+
+	case dialect.MSSQL:
+		if tsqlBatchRe.MatchString(line) {
+			s.resetStatement()
+			return true, s.canSplit()
+		}
+
+	case dialect.MySQL, dialect.MariaDB:
+		if sm := mySQLDelimRe.FindStringSubmatch(line); sm != nil {
+			s.delim = sm[1]
+			return true, !s.inStmt && s.canSplit()
+		}
+	}
+
+	return false, false
+}
+
+// chkDelimitedStart checks for the start of a dollar-quoted string or an
+// Oracle alternative quoted string at the specified position of the line
+// and, if found, updates the state of the reader and returns the position of
+// the end of the opening quote
+func (s *StatementReader) chkDelimitedStart(line string, idx int) (int, bool) {
+
+	closer := ""
+	iStart := idx
+
+	switch line[idx] {
+	case '$':
+		tag := s.p.dollarQuoteTag(line, idx)
+		if tag == "" {
+			return idx, false
+		}
+		closer = tag
+		iStart = idx + len(tag)
+
+	case '\'':
+		if !s.p.isAltQuoteStart(line, idx) {
+			return idx, false
+		}
+
+		var closers = map[byte]byte{'[': ']', '{': '}', '(': ')', '<': '>'}
+
+		delim := line[idx+1]
+		if c, ok := closers[delim]; ok {
+			delim = c
+		}
+		closer = string(delim) + "'"
+		iStart = idx + 2
+
+	default:
+		return idx, false
+	}
+
+	s.inStmt = true
+	s.escapable = false
+	s.closer = closer
+
+	return iStart - 1, true
+}
+
+// addWord updates the state of the current statement for the next word
+// (keyword, identifier, number, etc.) of the statement
+func (s *StatementReader) addWord(d int, w string) {
+
+	s.inStmt = true
+	if len(s.words) < 8 {
+		s.words = append(s.words, w)
+	}
+
+	if s.pendingBegin {
+		s.pendingBegin = false
+		switch d {
+		case dialect.PostgreSQL, dialect.StandardSQL:
+			// BEGIN ATOMIC ... END
+			if w == "ATOMIC" {
+				s.blockDepth++
+			}
+		default:
+			switch w {
+			case "TRANSACTION", "TRAN", "WORK", "DEFERRED", "IMMEDIATE", "EXCLUSIVE":
+			default:
+				s.blockDepth++
+			}
+		}
+	}
+
+	switch w {
+	case "BEGIN":
+		s.pendingBegin = true
+	case "CASE":
+		s.blockDepth++
+	case "END":
+		if s.blockDepth > 0 {
+			s.blockDepth--
+		}
+	case "STDIN":
+		s.isCopyStdin = d == dialect.PostgreSQL && s.words[0] == "COPY"
+	}
+
+	if d == dialect.Oracle && !s.isPL {
+		s.isPL = isOraPLStart(s.words)
+	}
+}
+
+// isOraPLStart determines whether or not the words that start a statement
+// indicate an Oracle PL unit (anonymous block, function, package, etc.)
+func isOraPLStart(words []string) bool {
+
+	for idx, w := range words {
+		switch w {
+		case "DECLARE", "BEGIN":
+			return idx == 0
+		case "CREATE", "OR", "REPLACE", "EDITIONABLE", "NONEDITIONABLE":
+			if words[0] != "CREATE" {
+				return false
+			}
+		case "FUNCTION", "PROCEDURE", "PACKAGE", "TRIGGER", "TYPE", "LIBRARY":
+			return words[0] == "CREATE"
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// isPsqlQueryCmd determines whether or not the string starts with a psql
+// command that sends the query buffer to the server
+func isPsqlQueryCmd(s string) bool {
+
+	args := strings.Fields(s)
+	if len(args) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "\\g", "\\gx", "\\gset", "\\gexec", "\\gdesc", "\\crosstabview", "\\watch":
+		return true
+	}
+	return false
+}
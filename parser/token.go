@@ -34,6 +34,22 @@ const (
 	BacktickQuoted
 	// BracketQuoted is a string enclosed in square brackets '[blah blah blah]`
 	BracketQuoted
+	// DollarQuoted is a string enclosed in matching dollar quote tags
+	//  '$tag$blah blah blah$tag$' (PostgreSQL)
+	DollarQuoted
+	// BitString is a bit string literal B'1010'
+	BitString
+	// HexString is a hexadecimal string literal X'1F'
+	HexString
+	// EscapeString is a string literal that allows C-style backslash
+	//  escapes E'a\nb' (PostgreSQL)
+	EscapeString
+	// UnicodeString is a string literal with Unicode escapes, and an
+	//  optional escape character, U&'d\0061t' [UESCAPE '!']
+	UnicodeString
+	// UnicodeIdentifier is a quoted identifier with Unicode escapes, and
+	//  an optional escape character, U&"d\0061t" [UESCAPE '!']
+	UnicodeIdentifier
 	// Label is a string that indicates a PL label (for Oracle
 	//  and PostgreSQL this means "enclosed in double greater that/less
 	//  than symbols '<< blah_blah_blah >>'"). For MySQL, MS-SQL, and
@@ -173,11 +189,19 @@ func (t *Token) SetType(tt int) error {
 		t.typeOf = tt
 		t.categoryOf = Comment
 	case BacktickQuoted,
+		BitString,
 		BracketQuoted,
+		DollarQuoted,
 		DoubleQuoted,
-		SingleQuoted:
+		EscapeString,
+		HexString,
+		SingleQuoted,
+		UnicodeString:
 		t.typeOf = tt
 		t.categoryOf = String
+	case UnicodeIdentifier:
+		t.typeOf = tt
+		t.categoryOf = Identifier
 	case Datatype:
 		t.typeOf = tt
 		t.categoryOf = tt
@@ -217,36 +241,42 @@ func (t *Token) name(i int) string {
 
 	var names = map[int]string{
 		//NullItem:         "NullItem",
-		Other:            "Other",
-		WhiteSpace:       "WhiteSpace",
-		Identifier:       "Identifier",
-		Numeric:          "Numeric",
-		Comment:          "Comment",
-		LineComment:      "LineComment",
-		PoundLineComment: "PoundLineComment",
-		BlockComment:     "BlockComment",
-		SingleQuoted:     "SingleQuoted",
-		DoubleQuoted:     "DoubleQuoted",
-		BacktickQuoted:   "BacktickQuoted",
-		BracketQuoted:    "BracketQuoted",
-		Label:            "Label",
-		Keyword:          "Keyword",
-		Operator:         "Operator",
-		BindParameter:    "BindParameter",
-		OpenParen:        "OpenParen",
-		CloseParen:       "CloseParen",
-		OpenBracket:      "OpenBracket",
-		CloseBracket:     "CloseBracket",
-		OpenBrace:        "OpenBrace",
-		CloseBrace:       "CloseBrace",
-		Comma:            "Comma",
-		SemiColon:        "SemiColon",
-		Datatype:         "Datatype",
-		Literal:          "Literal",
-		String:           "String",
-		Punctuation:      "Punctuation",
-		End:              "End",
-		Data:             "Data",
+		Other:             "Other",
+		WhiteSpace:        "WhiteSpace",
+		Identifier:        "Identifier",
+		Numeric:           "Numeric",
+		Comment:           "Comment",
+		LineComment:       "LineComment",
+		PoundLineComment:  "PoundLineComment",
+		BlockComment:      "BlockComment",
+		SingleQuoted:      "SingleQuoted",
+		DoubleQuoted:      "DoubleQuoted",
+		BacktickQuoted:    "BacktickQuoted",
+		BracketQuoted:     "BracketQuoted",
+		DollarQuoted:      "DollarQuoted",
+		BitString:         "BitString",
+		HexString:         "HexString",
+		EscapeString:      "EscapeString",
+		UnicodeString:     "UnicodeString",
+		UnicodeIdentifier: "UnicodeIdentifier",
+		Label:             "Label",
+		Keyword:           "Keyword",
+		Operator:          "Operator",
+		BindParameter:     "BindParameter",
+		OpenParen:         "OpenParen",
+		CloseParen:        "CloseParen",
+		OpenBracket:       "OpenBracket",
+		CloseBracket:      "CloseBracket",
+		OpenBrace:         "OpenBrace",
+		CloseBrace:        "CloseBrace",
+		Comma:             "Comma",
+		SemiColon:         "SemiColon",
+		Datatype:          "Datatype",
+		Literal:           "Literal",
+		String:            "String",
+		Punctuation:       "Punctuation",
+		End:               "End",
+		Data:              "Data",
 	}
 
 	if name, ok := names[i]; ok {
@@ -0,0 +1,18 @@
+-- sqlfmt d:mssql
+/* setup
+GO
+*/
+select 1;
+GO
+
+select 'a
+GO
+b' as x;
+GO
+
+/*
+:setvar schema dbo
+*/
+select [GO
+] from dbo.t;
+GO
@@ -0,0 +1,42 @@
+-- sqlfmt d:mssql
+CREATE FUNCTION dbo.fn_total (
+    @id int,
+    @since date )
+RETURNS decimal (10,2)
+WITH SCHEMABINDING
+AS
+BEGIN
+    DECLARE @t decimal (10,2)
+    SELECT @t = sum ( amount )
+        FROM dbo.sales
+        WHERE cust_id = @id
+    RETURN isnull ( @t, 0 )
+END
+go
+CREATE TRIGGER dbo.trg_sales
+    ON dbo.sales
+    AFTER INSERT,
+        UPDATE
+AS
+BEGIN
+    SET NOCOUNT ON
+    DECLARE c CURSOR LOCAL FAST_FORWARD FOR
+        SELECT id
+            FROM inserted
+    OPEN c
+    FETCH NEXT FROM c INTO @id
+    WHILE @@fetch_status = 0
+    BEGIN
+        EXEC dbo.usp_touch @id = @id
+        FETCH NEXT FROM c INTO @id
+    END
+    CLOSE c
+    DEALLOCATE c
+END
+go
+CREATE TABLE dbo.t ( a int REFERENCES dbo.u ( a ) ON DELETE CASCADE )
+SELECT a
+    FROM dbo.t
+SELECT b
+    FROM dbo.u
+go
@@ -0,0 +1,49 @@
+-- sqlfmt d:mssql
+SET NOCOUNT ON ;
+go
+CREATE OR ALTER PROCEDURE dbo.usp_report
+    @start_date date,
+    @end_date date = NULL,
+    @total int OUTPUT
+AS
+BEGIN
+    SET NOCOUNT ON ;
+    DECLARE @rows int = 0,
+            @msg nvarchar (200) ;
+    IF @end_date IS NULL
+        SET @end_date = getdate () ;
+    BEGIN TRY
+        BEGIN TRAN
+        UPDATE dbo.sales
+            SET processed = 1
+            WHERE sale_date BETWEEN @start_date AND @end_date
+        SET @rows = @@rowcount
+        IF @rows > 0
+        BEGIN
+            PRINT 'updated' ;
+            INSERT INTO dbo.audit ( rows_updated )
+                VALUES ( @rows )
+        END
+        ELSE IF @rows < 0
+            PRINT 'odd'
+        ELSE
+        BEGIN
+            RAISERROR ( 'nothing', 10, 1 ) ;
+        END
+        COMMIT TRAN
+    END TRY
+    BEGIN CATCH
+        ROLLBACK TRAN ;
+        THROW ;
+    END CATCH
+    SELECT @total = count (*)
+        FROM dbo.sales
+        WHERE processed = CASE WHEN @rows > 0 THEN 1 ELSE 0 END
+END
+go
+DECLARE @i int = 0
+WHILE @i < 10
+BEGIN
+    SET @i = @i + 1
+END
+go
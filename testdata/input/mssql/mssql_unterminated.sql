@@ -0,0 +1,35 @@
+-- sqlfmt d:mssql
+
+select a from t where x = 1
+select b from u
+delete from v where y = 2
+insert into w (a, b) select a, b from t where x = 3
+insert into w (a, b) values (1, 2)
+update t set x = 4 from t inner join u on u.b = t.a where t.a = 5
+select a, b from t union all select a, b from u
+insert into w (a, b) exec dbo.get_w
+select a from t with (nolock) where x = 6
+merge into w using t on w.a = t.a
+when matched then update set w.b = t.b
+when not matched by target then insert (a, b) values (t.a, t.b)
+when not matched by source then delete;
+with cte as (select a from t) update w set b = 1 from w join cte on cte.a = w.a
+select c from w
+create table z (id int primary key, v_id int references v (id) on delete cascade on update no action)
+select c from z
+GO
+
+create procedure dbo.p_test @id int
+as
+begin
+    set nocount on
+    select @id = a from t where x = @id
+    update t set x = @id where a = 1
+    if @id > 1
+        delete from v where y = @id
+    else
+        insert into w (a) select a from t
+    declare c cursor for select a from t for update of a
+    select b from u
+end
+GO
@@ -0,0 +1,7 @@
+-- sqlfmt d:mysql
+SELECT 'it\'s escaped' AS a,
+        "a \"quoted\" word" AS b,
+        'C:\\' AS win_root,
+        'ends in a backslash\\' AS c ;
+
+INSERT INTO paths ( path ) VALUES ( 'D:\\data\\' ) ;
@@ -0,0 +1,13 @@
+-- sqlfmt d:mysql
+DELIMITER $$
+
+SELECT 'not $$ a delimiter' AS a $$
+
+-- nor is this $$
+CREATE TABLE t_delim (
+        a int,
+        b varchar ( 20 ) ) $$
+
+DELIMITER ;
+
+SELECT 1 ;
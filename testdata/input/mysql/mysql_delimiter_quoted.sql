@@ -0,0 +1,20 @@
+-- sqlfmt d:mysql
+/*
+delimiter //
+*/
+select 1;
+
+select 'a
+delimiter //
+b' as x;
+
+# delimiter //
+delimiter $$
+select 2 $$
+/*
+delimiter ;
+*/
+select 3 $$
+delimiter ;
+
+select 4;
@@ -0,0 +1,54 @@
+-- sqlfmt d:mysql
+DELIMITER //
+
+CREATE FUNCTION fiscal_quarter (
+    p_date date )
+RETURNS int
+DETERMINISTIC
+NO SQL
+BEGIN
+    DECLARE v_month int ;
+    DECLARE v_quarter int DEFAULT 0 ;
+
+    SET v_month = month ( p_date ) ;
+
+    CASE
+        WHEN v_month IN ( 7, 8, 9 ) THEN
+            SET v_quarter = 1 ;
+        WHEN v_month IN ( 10, 11, 12 ) THEN
+            SET v_quarter = 2 ;
+        ELSE
+            SET v_quarter = 3 ;
+    END CASE ;
+
+    IF v_month IN ( 4, 5, 6 ) THEN
+        SET v_quarter = 4 ;
+    ELSEIF v_month = 0 THEN
+        SET v_quarter = NULL ;
+    END IF ;
+
+    RETURN v_quarter ;
+END //
+
+CREATE FUNCTION sum_to (
+    p_limit int )
+RETURNS int
+DETERMINISTIC
+BEGIN
+    DECLARE v_idx int DEFAULT 0 ;
+    DECLARE v_sum int DEFAULT 0 ;
+
+    WHILE v_idx < p_limit DO
+        SET v_idx = v_idx + 1 ;
+        SET v_sum = v_sum + v_idx ;
+    END WHILE ;
+
+    REPEAT
+        SET v_idx = v_idx - 1 ;
+    UNTIL v_idx <= 0
+    END REPEAT ;
+
+    RETURN v_sum ;
+END //
+
+DELIMITER ;
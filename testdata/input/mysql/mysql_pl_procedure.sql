@@ -0,0 +1,43 @@
+-- sqlfmt d:mysql
+DROP PROCEDURE IF EXISTS count_orders ;
+
+DELIMITER $$
+
+CREATE PROCEDURE count_orders (
+    IN p_customer_id int,
+    OUT p_count int )
+READS SQL DATA
+COMMENT 'Count the orders for a customer'
+BEGIN
+    DECLARE v_done int DEFAULT 0 ;
+    DECLARE v_total int DEFAULT 0 ;
+    DECLARE v_amount decimal (10,2) ;
+    DECLARE cur_orders CURSOR FOR
+        SELECT amount
+            FROM orders
+            WHERE customer_id = p_customer_id ;
+    DECLARE CONTINUE HANDLER FOR NOT FOUND SET v_done = 1 ;
+    DECLARE EXIT HANDLER FOR SQLEXCEPTION
+    BEGIN
+        ROLLBACK ;
+        RESIGNAL ;
+    END ;
+
+    OPEN cur_orders ;
+
+    read_loop: LOOP
+        FETCH cur_orders INTO v_amount ;
+        IF v_done = 1 THEN
+            LEAVE read_loop ;
+        END IF ;
+        SET v_total = v_total + 1 ;
+    END LOOP read_loop ;
+
+    CLOSE cur_orders ;
+
+    SET p_count = v_total ;
+END $$
+
+DELIMITER ;
+
+CALL count_orders ( 42, @cnt ) ;
@@ -0,0 +1,23 @@
+-- sqlfmt d:mysql
+DELIMITER $$
+
+CREATE TRIGGER orders_bi
+    BEFORE INSERT ON orders
+    FOR EACH ROW
+BEGIN
+    IF new.amount < 0 THEN
+        SIGNAL SQLSTATE '45000'
+            SET MESSAGE_TEXT = 'Amount can not be negative' ;
+    END IF ;
+    SET new.created_at = current_timestamp ;
+END $$
+
+CREATE TRIGGER orders_ai
+    AFTER INSERT ON orders
+    FOR EACH ROW
+BEGIN
+    INSERT INTO order_log ( order_id, logged_at )
+        VALUES ( new.id, current_timestamp ) ;
+END $$
+
+DELIMITER ;
@@ -0,0 +1,13 @@
+-- sqlfmt d:oracle
+SELECT q'[It's a "quoted" string; with -- stuff]' AS a,
+        Q'{Unbalanced ' quote}' AS b,
+        nq'<National ' string>' AS c,
+        q'!Bang delimited ' string!' AS d,
+        q'(Parens ( inside ) and ' quote)' AS e
+    FROM dual ;
+
+BEGIN
+    EXECUTE IMMEDIATE q'[UPDATE hr.employees SET note = 'it''s; done' WHERE 1 = 1]' ;
+    dbms_output.put_line ( q'{Done; isn't it}' ) ;
+END ;
+/
@@ -0,0 +1,19 @@
+-- sqlfmt d:oracle
+
+create or replace procedure label_test (a_max in number) is
+l_total number := 0;
+begin
+<<outer_loop>> for i in 1..a_max loop
+<<inner_loop>>
+for j in 1..a_max loop
+l_total := l_total + j;
+exit outer_loop when l_total > 100;
+end loop inner_loop;
+end loop outer_loop;
+<<nested>> begin
+l_total := l_total + 1;
+end nested;
+goto the_end;
+<<the_end>> null;
+end label_test;
+/
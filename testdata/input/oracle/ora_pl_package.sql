@@ -0,0 +1,87 @@
+-- sqlfmt d:oracle
+CREATE OR REPLACE PACKAGE hr.emp_mgmt
+AUTHID DEFINER
+AS
+    -- Exceptions
+    e_no_such_emp EXCEPTION;
+    PRAGMA EXCEPTION_INIT ( e_no_such_emp, -20001 );
+
+    TYPE t_emp_tab IS TABLE OF hr.employees%ROWTYPE INDEX BY PLS_INTEGER;
+
+    CURSOR c_dept_emps ( p_dept_id IN NUMBER ) RETURN hr.employees%ROWTYPE;
+
+    FUNCTION get_salary ( p_emp_id IN NUMBER ) RETURN NUMBER;
+
+    PROCEDURE raise_salary ( p_emp_id IN NUMBER, p_pct IN NUMBER DEFAULT 10 );
+
+    PROCEDURE archive_dept ( p_dept_id IN NUMBER );
+END emp_mgmt;
+/
+
+CREATE OR REPLACE PACKAGE BODY hr.emp_mgmt
+AS
+    CURSOR c_dept_emps ( p_dept_id IN NUMBER ) RETURN hr.employees%ROWTYPE IS
+        SELECT *
+            FROM hr.employees
+            WHERE department_id = p_dept_id;
+
+    FUNCTION get_salary ( p_emp_id IN NUMBER ) RETURN NUMBER
+    IS
+        l_salary hr.employees.salary%TYPE;
+    BEGIN
+        SELECT salary
+            INTO l_salary
+            FROM hr.employees
+            WHERE employee_id = p_emp_id;
+        RETURN l_salary;
+    EXCEPTION
+        WHEN NO_DATA_FOUND THEN
+            RAISE e_no_such_emp;
+        WHEN OTHERS THEN
+            RAISE;
+    END get_salary;
+
+    PROCEDURE raise_salary ( p_emp_id IN NUMBER, p_pct IN NUMBER DEFAULT 10 )
+    IS
+        PRAGMA AUTONOMOUS_TRANSACTION;
+
+        PROCEDURE log_change ( p_msg IN VARCHAR2 )
+        IS
+        BEGIN
+            INSERT INTO hr.change_log ( msg ) VALUES ( p_msg );
+        END log_change;
+    BEGIN
+        UPDATE hr.employees
+            SET salary = salary * ( 1 + p_pct / 100 )
+            WHERE employee_id = p_emp_id;
+        IF SQL%ROWCOUNT = 0 THEN
+            RAISE e_no_such_emp;
+        END IF;
+        log_change ( 'Raised ' || p_emp_id );
+        COMMIT;
+    EXCEPTION
+        WHEN e_no_such_emp THEN
+            ROLLBACK;
+            RAISE_APPLICATION_ERROR ( -20001, 'No such employee' );
+    END raise_salary;
+
+    PROCEDURE archive_dept ( p_dept_id IN NUMBER )
+    IS
+        l_emps t_emp_tab;
+    BEGIN
+        OPEN c_dept_emps ( p_dept_id );
+        FETCH c_dept_emps BULK COLLECT INTO l_emps LIMIT 100;
+        CLOSE c_dept_emps;
+
+        FORALL i IN 1 .. l_emps.COUNT
+            INSERT INTO hr.employees_archive VALUES l_emps ( i );
+
+        FORALL i IN INDICES OF l_emps
+            DELETE FROM hr.employees WHERE employee_id = l_emps ( i ).employee_id;
+
+        FOR r IN c_dept_emps ( p_dept_id ) LOOP
+            NULL;
+        END LOOP;
+    END archive_dept;
+END emp_mgmt;
+/
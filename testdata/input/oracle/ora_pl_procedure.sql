@@ -0,0 +1,64 @@
+-- sqlfmt d:oracle
+CREATE OR REPLACE PROCEDURE hr.sync_emps (
+    p_dept_id IN NUMBER,
+    p_count OUT NUMBER )
+AS
+    CURSOR c_emps IS
+        SELECT employee_id, salary
+            FROM hr.employees
+            WHERE department_id = p_dept_id
+            FOR UPDATE;
+
+    TYPE t_ids IS TABLE OF NUMBER;
+    l_ids t_ids;
+    l_salaries DBMS_SQL.NUMBER_TABLE;
+BEGIN
+    SELECT employee_id BULK COLLECT INTO l_ids FROM hr.employees WHERE department_id = p_dept_id;
+
+    FORALL i IN 1 .. l_ids.COUNT SAVE EXCEPTIONS
+        UPDATE hr.employees SET salary = salary + 1 WHERE employee_id = l_ids ( i );
+
+    p_count := l_ids.COUNT;
+
+    FOR r IN c_emps LOOP
+        CASE
+            WHEN r.salary > 1000 THEN
+                l_salaries ( r.employee_id ) := r.salary;
+            ELSE
+                NULL;
+        END CASE;
+    END LOOP;
+EXCEPTION
+    WHEN DUP_VAL_ON_INDEX OR VALUE_ERROR THEN
+        p_count := -1;
+    WHEN OTHERS THEN
+        p_count := -2;
+        RAISE;
+END sync_emps;
+/
+
+CREATE OR REPLACE TYPE BODY hr.t_point
+AS
+    MEMBER FUNCTION dist ( p IN t_point ) RETURN NUMBER
+    IS
+    BEGIN
+        RETURN SQRT ( POWER ( x - p.x, 2 ) + POWER ( y - p.y, 2 ) );
+    END dist;
+
+    CONSTRUCTOR FUNCTION t_point ( x NUMBER ) RETURN SELF AS RESULT
+    IS
+    BEGIN
+        SELF.x := x;
+        SELF.y := 0;
+        RETURN;
+    END;
+END;
+/
+
+DECLARE
+    l_cnt NUMBER := 0;
+BEGIN
+    hr.sync_emps ( 10, l_cnt );
+    DBMS_OUTPUT.PUT_LINE ( 'Count: ' || l_cnt );
+END;
+/
@@ -0,0 +1,19 @@
+-- sqlfmt d:oracle
+CREATE OR REPLACE TRIGGER hr.emp_audit_trg
+BEFORE INSERT OR UPDATE ON hr.employees
+FOR EACH ROW
+DECLARE
+    e_bad_salary EXCEPTION;
+    PRAGMA EXCEPTION_INIT ( e_bad_salary, -20010 );
+BEGIN
+    IF :NEW.salary < 0 THEN
+        RAISE e_bad_salary;
+    ELSIF :NEW.salary > 100000 THEN
+        :NEW.salary := 100000;
+    END IF;
+    INSERT INTO hr.emp_audit ( employee_id, changed_on ) VALUES ( :NEW.employee_id, SYSDATE );
+EXCEPTION
+    WHEN e_bad_salary THEN
+        RAISE_APPLICATION_ERROR ( -20010, 'Bad salary' );
+END;
+/
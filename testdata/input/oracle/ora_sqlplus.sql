@@ -0,0 +1,35 @@
+-- sqlfmt d:oracle
+SET DEFINE OFF
+SET SERVEROUTPUT ON SIZE UNLIMITED
+WHENEVER SQLERROR EXIT SQL.SQLCODE ROLLBACK
+SPOOL deploy_&&env..log
+
+PROMPT Creating table &&schema..emp_audit
+CREATE TABLE &&schema..emp_audit (
+    employee_id NUMBER NOT NULL,
+    changed_on DATE DEFAULT SYSDATE );
+
+REM Populate the audit table
+INSERT INTO &&schema..emp_audit ( employee_id )
+    SELECT employee_id FROM hr.employees WHERE department_id = &dept_id;
+
+UPDATE hr.employees
+SET salary = salary * 1.1
+WHERE department_id = &dept_id;
+
+UPDATE hr.shifts
+SET time = sysdate WHERE id = 1;
+
+update hr.shifts
+set tab = 'x', echo = 'y' where id = 2;
+
+@@child_script.sql
+@other_script.sql
+
+BEGIN
+    DBMS_OUTPUT.PUT_LINE ( 'Done with &&schema' );
+END;
+/
+
+SPOOL OFF
+EXIT
@@ -0,0 +1,20 @@
+-- sqlfmt d:oracle
+PROMPT Loading the notes
+
+/*
+show the results
+*/
+SELECT 1 FROM dual;
+
+/*
+    set define off
+    prompt this is not a command either
+*/
+insert into hr.notes (note_id, note) values (1, 'line one
+prompt text');
+
+insert into hr.notes (note_id, note) values (2, q'[it's
+spool off]');
+
+-- A command after the string is still recognized
+SHOW ERRORS
@@ -0,0 +1,11 @@
+-- sqlfmt d:postgres
+SELECT 'C:\' AS win_root,
+        'C:\temp\' AS win_temp,
+        E'it\'s escaped\\' AS escaped,
+        e'tab\there' AS lower_e,
+        'no\' || 'escape' AS concat ;
+
+-- A comment ending in a backslash \
+SELECT 'a\' AS a ;
+
+INSERT INTO foo.paths ( path ) VALUES ( 'D:\data\' ), ( E'\\\\server\\share\\' ) ;
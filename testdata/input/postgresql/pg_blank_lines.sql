@@ -0,0 +1,43 @@
+-- sqlfmt d:postgres; maxBlankLines:1; blankLinesBetweenStatements:1
+
+CREATE TABLE blank_lines_a (
+    id integer NOT NULL,
+    label text ) ;
+CREATE TABLE blank_lines_b (
+    id integer NOT NULL,
+
+
+
+    label text ) ;
+
+
+
+
+-- Comments preceding a statement are kept with the statement
+COMMENT ON TABLE blank_lines_b IS 'Second table' ;
+GRANT SELECT ON blank_lines_a TO some_role ;
+GRANT SELECT ON blank_lines_b TO some_role ;
+
+
+
+CREATE OR REPLACE FUNCTION blank_lines_f ()
+RETURNS integer
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    x integer ;
+
+
+
+    y integer ;
+BEGIN
+    x := 1 ;
+
+
+
+
+    y := 2 ;
+    RETURN x + y ;
+END ;
+$$ ;
+SELECT blank_lines_f () ;
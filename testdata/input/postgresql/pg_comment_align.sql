@@ -0,0 +1,24 @@
+-- sqlfmt d:postgres; alignComments
+create table t_align (
+    id integer not null, -- the id
+    name text, -- the name
+    some_longer_column_name timestamp with time zone default now(), -- when
+    flag boolean, -- a comment that would make the line too long if it were aligned with the other comments in the run
+
+    other_id integer not null, -- the comments after a blank line are aligned separately
+    description text -- the description
+) ;
+
+select a, -- first
+    bb, -- second
+    ccc.dddd -- third
+  from t ;
+
+create function f_align () returns int language plpgsql as $$
+declare
+    a int := 1; -- one
+    bbbb text; -- two
+begin
+    return a; -- a single trailing comment is not moved
+end;
+$$ ;
@@ -0,0 +1,23 @@
+-- sqlfmt d:postgres
+CREATE OR REPLACE FUNCTION f ()
+RETURNS integer
+LANGUAGE plpgsql
+AS $$
+BEGIN
+  IF true THEN
+  /***********************
+   * Box drawn comment   *
+   ***********************/
+  RETURN 1 ;
+  END IF ;
+	/* tab indented
+	   second line
+	     deeper line */
+  RETURN 0 ;
+END ;
+$$ ;
+
+    /*
+     * top level, originally indented
+     */
+SELECT 1 ;
@@ -0,0 +1,47 @@
+-- sqlfmt d:postgres; wrapComments
+
+-- This paragraph of line comment prose is long enough that it needs to be reflowed to fit within the max line length that has been configured for the formatting.
+-- And this line continues the same paragraph.
+--
+-- Lists, URLs, and code are left alone:
+-- - the first item in a list that is far too long to fit on a single line but which is not reflowed
+-- See https://www.postgresql.org/docs/current/sql-createtable.html for the details on the create table options
+-- SELECT col_01, col_02, col_03, col_04, col_05, col_06, col_07, col_08 FROM some_schema.some_table ;
+
+/* A block comment with prose that is also long enough that it needs to be reflowed to fit within the limit of the line length that has been configured. */
+create table t_wrap (
+    id integer not null,
+    /*
+     * Block comments with a star gutter are reflowed using the same gutter for the lines that are added by the reflow.
+     *
+     * | col    | description                                                                     |
+     * | ------ | ------------------------------------------------------------------------------- |
+     */
+    name text ) ;
+
+select 1 ; -- a trailing comment that is long is not reflowed since that would move it to another line entirely
+
+select col_01,
+        -- An indented line comment in a select list that runs on for long enough that it has to be reflowed to fit the max line length.
+        col_02
+    from t_wrap
+    where id > 0
+        /* An indented block comment in a where clause that runs on for long enough that it also has to be reflowed to fit the max line length. */
+        and name is not null ;
+
+create function f_wrap () returns integer language plpgsql as $$
+declare
+    -- A line comment in the declare section of a function body that is long enough that it needs to be reflowed to fit within the max line length.
+    l_cnt integer := 0 ;
+begin
+    if l_cnt = 0 then
+        -- A line comment nested two levels deep in a function body that is long enough that it needs to be reflowed to fit within the max line length.
+        -- This second line is part of the same paragraph and is reflowed along with the first line.
+        l_cnt := 1 ;
+        /*
+            A block comment without a gutter that is nested two levels deep in a function body and is long enough that it needs to be reflowed.
+        */
+    end if ;
+    return l_cnt ;
+end ;
+$$ ;
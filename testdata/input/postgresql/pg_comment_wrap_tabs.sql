@@ -0,0 +1,21 @@
+-- sqlfmt d:postgres; wrapComments; indent:0; xl:80
+
+select col_01,
+        -- An indented line comment in a select list that runs on for long enough that it has to be reflowed.
+        col_02
+    from t_wrap
+    where id > 0
+        /* An indented block comment in a where clause that runs on for long enough that it also has to be reflowed. */
+        and name is not null ;
+
+create function f_wrap_tabs () returns integer language plpgsql as $$
+declare
+    l_cnt integer := 0 ;
+begin
+    if l_cnt = 0 then
+        -- A line comment nested two levels deep in a function body that is long enough that it needs to be reflowed.
+        l_cnt := 1 ;
+    end if ;
+    return l_cnt ;
+end ;
+$$ ;
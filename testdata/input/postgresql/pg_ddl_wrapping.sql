@@ -0,0 +1,16 @@
+-- sqlfmt d:postgresql; xl:80
+create index orders_open_ix on app_data.orders (customer_id, status) where status <> 'delivered' and status <> 'cancelled' and status <> 'refunded';
+
+create table app_data.orders (order_id integer not null, customer_id integer not null, status text not null, quantity integer not null, unit_price numeric(12,2) not null, discount_pct numeric(5,2) not null default 0, total_price numeric(14,2) generated always as (quantity * unit_price * (1 - discount_pct / 100) + coalesce(shipping_cost, 0)) stored, shipping_cost numeric(12,2), constraint orders_status_ck check (status in ('new', 'pending', 'shipped', 'delivered', 'cancelled', 'returned', 'refunded')), constraint orders_qty_ck check (quantity > 0 and quantity < 10000 and unit_price >= 0 and discount_pct between 0 and 100));
+
+create policy orders_owner_pol on app_data.orders for select to app_user using (customer_id = current_setting('app.customer_id')::integer or pg_has_role('app_admin', 'member'));
+
+grant select, insert, update, delete, truncate, references, trigger on table app_data.orders to app_owner_role, app_reporting_role;
+
+grant select (order_id, customer_id, status, quantity, unit_price, discount_pct, total_price, shipping_cost), update (status, quantity, shipping_cost) on app_data.orders to app_reporting_role with grant option;
+
+revoke select, insert, update, delete, truncate, references, trigger on table app_data.orders from app_owner_role, app_reporting_role;
+
+alter table app_data.orders add constraint orders_shipping_ck check (shipping_cost is null or (shipping_cost >= 0 and shipping_cost <= quantity * unit_price));
+
+alter table app_data.orders alter column status drop default, add column notes_text text not null default 'none', drop column legacy_code;
@@ -0,0 +1,29 @@
+-- sqlfmt d:postgres
+COMMENT ON TABLE foo.bar IS $$The bar table; it's where "things" -- go$$ ;
+
+COMMENT ON FUNCTION foo.baz ( integer ) IS $cmt$Returns the baz; or else$cmt$ ;
+
+SELECT $$It's a string; with -- stuff$$ AS a, $x$;$x$ AS b ;
+
+CREATE OR REPLACE FUNCTION foo.exec_it ( p_table text )
+RETURNS void
+LANGUAGE plpgsql
+AS $body$
+DECLARE
+    l_sql text := $q$DELETE FROM foo.bar WHERE note = 'x; y' -- not a comment$q$ ;
+BEGIN
+    EXECUTE $q$UPDATE foo.bar SET note = 'it''s; done'$q$ ;
+    EXECUTE format ( $fmt$TRUNCATE TABLE %I$fmt$, p_table ) ;
+    RAISE NOTICE $$Done; with %$$, p_table ;
+END ;
+$body$ ;
+
+CREATE FUNCTION foo.add_one ( integer ) RETURNS integer
+    AS $$ SELECT $1 + 1 $$
+    LANGUAGE SQL ;
+
+DO $do$
+BEGIN
+    PERFORM foo.exec_it ( $t$bar$t$ ) ;
+END ;
+$do$ ;
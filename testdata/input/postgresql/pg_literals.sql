@@ -0,0 +1,15 @@
+-- sqlfmt d:postgres
+SELECT U&'d\0061t\+000061' AS a1,
+        u&'d!0061t!+000061' UESCAPE '!' AS a2,
+        U&"d!0061t" UESCAPE '!' AS a3,
+        U&"Foo" AS a4,
+        B'1010' AS b1,
+        b'01' AS b2,
+        X'1F' AS x1,
+        x'ff' AS x2,
+        E'a\nb' AS e1,
+        e'it\'s' AS e2,
+        'plain' AS s1
+    FROM t ;
+
+CREATE TABLE U&"t!0061b" UESCAPE '!' ( id int, flags bit (4) DEFAULT B'0000' ) ;
@@ -0,0 +1,13 @@
+-- sqlfmt d:postgres
+/* An outer comment
+    /* with a nested comment; SELECT 1 ; */
+    that continues; DELETE FROM foo.bar ;
+*/
+SELECT a, /* inline /* nested */ comment */ b
+    FROM foo.bar ;
+
+/**/
+SELECT 1 ; /* trailing /* nested /* deeply */ */ comment */
+
+/*/ not closed by the slash star slash */
+SELECT 2 ;
@@ -0,0 +1,25 @@
+-- sqlfmt d:postgres; parenPadding:off; spaceBeforeTerminator:off
+CREATE SERVER foreign_server
+    FOREIGN DATA WRAPPER postgres_fdw
+    OPTIONS ( hostaddr '127.0.0.1', port '5432', dbname 'foreign_db' ) ;
+
+CREATE TABLE foo.bar (
+    id integer NOT NULL,
+    label varchar ( 30 ),
+    CONSTRAINT bar_pk PRIMARY KEY ( id ) ) ;
+
+SELECT count ( * ), coalesce ( a, ( b + 1 ) )
+    FROM foo.bar
+    WHERE id IN ( 1, 2, 3 ) ;
+
+CREATE OR REPLACE FUNCTION foo.baz ( a integer )
+RETURNS integer
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    IF ( a > 0 ) THEN
+        RETURN ( a + 1 ) ;
+    END IF ;
+    RETURN 0 ;
+END ;
+$$ ;
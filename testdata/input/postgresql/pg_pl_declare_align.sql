@@ -0,0 +1,24 @@
+-- sqlfmt d:postgres; alignDeclarations
+create or replace function f_declare_align (a_id integer) returns text language plpgsql as $$
+declare
+    r record;
+    l_name character varying(30) not null := 'x';
+    c_max constant integer := 10;
+    l_ts timestamp (3) := clock_timestamp () at time zone 'UTC' ;
+
+    l_src_action text := 'none';
+    l_tgt_action text := 'none';
+    l_arr text[];
+    l_row foo.bar%rowtype;
+begin
+
+    declare
+        l_inner integer;
+        l_inner_name text;
+    begin
+        l_inner := a_id;
+    end;
+
+    return l_name;
+end;
+$$ ;
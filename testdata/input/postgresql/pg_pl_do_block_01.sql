@@ -0,0 +1,30 @@
+-- sqlfmt d:postgres
+
+-- DO blocks get the same DECLARE, BEGIN, EXCEPTION, and END treatment as
+-- function bodies
+do $$
+declare
+    r record;
+    l_cnt integer := 0;
+begin
+    for r in select table_name from information_schema.tables where table_schema = 'public' loop
+        perform pg_notify('tables', r.table_name);
+        l_cnt := l_cnt + 1;
+    end loop;
+
+    begin
+        if l_cnt = 0 then
+            raise exception 'no tables found';
+        end if;
+    exception
+        when others then
+            raise notice 'failed: %', sqlerrm;
+    end;
+end;
+$$;
+
+do $body$
+begin
+    execute 'vacuum analyze foo.bar';
+end
+$body$;
@@ -0,0 +1,28 @@
+-- sqlfmt d:postgres
+
+-- The LANGUAGE may be specified before or after the body
+DO language plpgsql $do$
+begin
+    perform 1;
+end
+$do$;
+
+do $$
+begin
+    perform 1;
+end;
+$$ language plpgsql;
+
+do $$
+begin
+    if true then
+        perform 1;
+    end if;
+end
+$$ language 'plpgsql';
+
+DO LANGUAGE plpgsql $$
+BEGIN
+    PERFORM 1;
+END
+$$;
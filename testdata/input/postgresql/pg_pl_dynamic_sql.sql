@@ -0,0 +1,15 @@
+-- sqlfmt d:postgres; formatDynamicSQL
+create function f_dynamic_sql (p_schema text, p_table text, p_note text, p_id integer) returns setof record language plpgsql as $body$
+declare
+    l_msg text;
+begin
+    execute $q$update foo.bar set note = 'done', updated_at = now() where id = $1 and status in ('a', 'b')$q$ using p_id;
+    execute format ( $fmt$select a.col_01, a.col_02, b.col_03 from %I.%I a join foo.baz b on b.id = a.id where a.note = %L and a.flag$fmt$, p_schema, p_table, p_note ) ;
+
+    -- single-quoted strings and strings that are not DML are left as is
+    execute 'delete from foo.bar where id = ' || p_id::text;
+    l_msg := format ( $fmt$Table %I.%I was updated$fmt$, p_schema, p_table );
+
+    return query execute $$select id, name from foo.bar where id = $1$$ using p_id;
+end;
+$body$ ;
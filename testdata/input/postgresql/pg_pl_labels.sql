@@ -0,0 +1,21 @@
+-- sqlfmt d:postgres
+
+create or replace function label_test (a_max integer) returns integer language plpgsql as $$
+<<outer_block>>
+declare
+l_total integer := 0;
+begin
+<<outer_loop>> for i in 1..a_max loop
+<<inner_loop>>
+for j in 1..a_max loop
+l_total := l_total + j;
+exit outer_loop when l_total > 100;
+continue inner_loop when j % 2 = 0;
+end loop inner_loop;
+end loop outer_loop;
+<<nested>> begin
+l_total := outer_block.l_total + 1;
+end nested;
+return l_total;
+end;
+$$;
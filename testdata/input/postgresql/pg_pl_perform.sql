@@ -0,0 +1,27 @@
+-- sqlfmt d:postgres
+
+create or replace function app_data.refresh_order_totals (
+    a_order_id integer )
+returns void
+language plpgsql
+as $$
+begin
+    perform pg_advisory_xact_lock ( a_order_id ) ;
+
+    if not app_data.order_exists ( a_order_id ) then
+        perform app_data.log_missing_order ( a_order_id ) ;
+        return ;
+    end if ;
+
+    perform app_data.recalc_order ( a_order_id, true ) ;
+end ;
+$$ ;
+
+create or replace procedure app_data.touch_order (
+    a_order_id integer )
+language plpgsql
+as $$
+begin
+    perform app_data.refresh_order_totals ( a_order_id ) ;
+end ;
+$$ ;
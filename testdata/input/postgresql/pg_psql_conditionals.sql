@@ -0,0 +1,20 @@
+-- sqlfmt d:postgres
+\set schema_name 'public'
+SELECT count (*) > 0 AS has_tbl
+    FROM information_schema.tables
+    WHERE table_schema = :'schema_name'
+        AND table_name = 'foo' \gset
+\if :has_tbl
+    -- drop it first
+    DROP TABLE :"schema_name".foo ;
+\elif :{?other}
+    SELECT 1 ;
+\else
+    SELECT 'no table' AS msg,
+            *
+        FROM :"schema_name".bar
+        WHERE id = :id
+    \gexec
+\endif
+SELECT 'a' \g out.txt
+SELECT :'x' AS y ;
@@ -0,0 +1,19 @@
+-- sqlfmt d:postgres; terminateStatements
+SET search_path = foo, public
+;
+
+SELECT a, b
+    FROM t1;
+
+UPDATE t3 SET a = 1 WHERE b = 2 ;
+
+CREATE OR REPLACE FUNCTION foo.bar ( a integer )
+RETURNS integer
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    RETURN a + 1;
+END;
+$$ ;
+
+DELETE FROM t4 WHERE c = 3 -- no terminator
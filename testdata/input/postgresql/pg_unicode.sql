@@ -0,0 +1,27 @@
+-- sqlfmt d:postgres
+-- 顧客テーブル
+SELECT café,
+        naïve_col,
+        "名前",
+        'ünïcödé' AS s
+    FROM données
+    WHERE prénom = 'José'
+        AND 城市 = '東京都'
+        AND a = 1 ;
+SELECT 名前,
+        住所,
+        電話番号,
+        電子メール,
+        生年月日,
+        登録日時,
+        更新日時,
+        作成者,
+        更新者,
+        備考
+    FROM 顧客マスタテーブル
+    WHERE 削除フラグ = 0 ;
+
+SELECT a
+    FROM t
+    WHERE 地域 IN ( '東京都', '大阪府', '名古屋市', '福岡県', '北海道', '京都府', '神奈川県', '埼玉県', '千葉県',
+            '兵庫県' ) ;
@@ -0,0 +1,31 @@
+-- sqlfmt d:postgres; wrapEngine:optimal
+
+SELECT coalesce ( func_01 ( 'foo', 'bar', 42 ), func_02 ( 'foo', 'bar', 42 ), func_03 ( 'foo', 'bar', 42 ),
+            func_04 ( 'foo', 'bar', 42 ), func_05 ( 'foo', 'bar', 42 ) ) AS col_01,
+        coalesce ( n.parameter_01_cnt, 0 ) + coalesce ( n.parameter_02_cnt, 0 ) + coalesce ( n.parameter_03_cnt, 0 )
+            + coalesce ( n.parameter_04_cnt, 0 ) AS col_02
+    FROM some_table n
+    WHERE n.id = 42 ;
+
+SELECT 'UPDATE ' || l_table_name || ' SET id = id + ' || l_id || ', other_id = ' || l_id_count || ' WHERE CURRENT OF '
+            || quote_ident ( l_cursor::text ) ;
+
+CREATE TABLE t_wrap (
+        total_price numeric GENERATED ALWAYS AS ( quantity * unit_price * ( 1 - discount_pct / 100 )
+                + coalesce ( shipping_cost, 0 ) ) stored,
+        CONSTRAINT t_wrap_ck CHECK (
+            status IN ( 'new', 'pending', 'shipped', 'delivered', 'cancelled', 'returned', 'refunded', 'lost' ) ) ) ;
+
+CREATE OR REPLACE FUNCTION wrap_test (
+    a_id integer )
+RETURNS integer
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    l_total integer ;
+BEGIN
+    l_total := coalesce ( some_long_function_name ( a_id, 'some parameter value' ),
+        another_long_function_name ( a_id, 'another value' ), 0 ) ;
+    RETURN l_total ;
+END ;
+$$ ;
@@ -0,0 +1,5 @@
+-- sqlfmt d:sqlite
+SELECT 'C:\' AS win_root,
+        'C:\temp\' AS win_temp ;
+
+INSERT INTO paths ( path ) VALUES ( 'D:\data\' ) ;
@@ -0,0 +1,46 @@
+-- sqlfmt d:sqlite
+
+/*
+References:
+https://www.sqlite.org/lang_createtrigger.html
+https://www.sqlite.org/lang_upsert.html
+https://www.sqlite.org/lang_returning.html
+https://www.sqlite.org/lang_createtable.html#rowid
+https://www.sqlite.org/stricttables.html
+*/
+
+create table inventory (item_id integer primary key, name text not null, qty integer) strict;
+
+create table item_tags (item_id integer, tag text, primary key (item_id, tag)) without rowid, strict;
+
+create view v_inventory as select item_id, name, qty from inventory;
+
+create trigger tr_receipts_upsert after insert on receipts
+when new.qty > 0 and new.item_id is not null
+begin
+    insert into inventory (item_id, name, qty) values (new.item_id, new.name, new.qty)
+        on conflict (item_id) do update set qty = qty + excluded.qty, name = excluded.name where excluded.qty > 0;
+    insert or ignore into item_tags (item_id, tag) values (new.item_id, 'received');
+end;
+
+create trigger tr_v_inventory_upd instead of update on v_inventory for each row
+begin
+    select raise(abort, 'item_id may not be changed') where new.item_id <> old.item_id;
+    select case when new.qty < 0 then raise(fail, 'qty may not be negative') end;
+    select raise(ignore) where new.name is null;
+    update inventory set name = new.name, qty = new.qty where item_id = old.item_id;
+end;
+
+create trigger tr_v_inventory_del instead of delete on v_inventory
+begin
+    select raise(rollback, 'items may not be deleted');
+end;
+
+insert into inventory (item_id, name, qty) values (1, 'widget', 10) on conflict do nothing returning *;
+
+insert into inventory (item_id, name, qty) values (2, 'gadget', 5)
+on conflict (item_id) do update set qty = qty + excluded.qty returning item_id, qty;
+
+update inventory set qty = qty - 1 where item_id = 1 returning item_id, qty as remaining;
+
+delete from inventory where qty = 0 returning item_id;
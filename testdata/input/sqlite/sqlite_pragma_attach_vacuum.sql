@@ -0,0 +1,26 @@
+-- sqlfmt d:sqlite
+
+/*
+References:
+https://www.sqlite.org/pragma.html
+https://www.sqlite.org/lang_attach.html
+https://www.sqlite.org/lang_detach.html
+https://www.sqlite.org/lang_vacuum.html
+*/
+
+pragma foreign_keys = on;
+PRAGMA main.journal_mode=wal;
+pragma synchronous = normal;
+pragma table_info('inventory');
+pragma schema.index_list(inventory);
+pragma integrity_check;
+
+attach database 'archive.db' as archive;
+attach 'reports.db' as reports;
+
+detach database archive;
+detach reports;
+
+vacuum;
+vacuum into '/tmp/backup.db';
+vacuum main into 'main_backup.db';